@@ -0,0 +1,66 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+// Adjacency of diagram cells in compressed sparse row format
+
+package voronoi
+
+// Neighbours of every diagram cell, stored in compressed sparse row
+// format. Neighbours of the i-th cell of Diagram.Cells are
+// Cells[Offsets[i]:Offsets[i+1]], ordered counterclockwise.
+type Adjacency struct {
+	// Start of neighbours of every cell in Cells, followed by their
+	// total count
+	Offsets []int
+	// Indices of neighbouring cells in Diagram.Cells
+	Cells []int
+	// Edge shared with every neighbour, parallel to Cells
+	Edges []*Edge
+}
+
+// Return adjacency of the diagram cells
+func (d *Diagram) Adjacency() *Adjacency {
+	cellIndex := make(map[*Cell]int, len(d.Cells))
+	for i, cell := range d.Cells {
+		cellIndex[cell] = i
+	}
+
+	ret := &Adjacency{Offsets: make([]int, 0, len(d.Cells)+1)}
+	for _, cell := range d.Cells {
+		ret.Offsets = append(ret.Offsets, len(ret.Cells))
+		for _, halfedge := range cell.Halfedges {
+			if other := halfedge.Edge.GetOtherCell(cell); other != nil {
+				ret.Cells = append(ret.Cells, cellIndex[other])
+				ret.Edges = append(ret.Edges, halfedge.Edge)
+			}
+		}
+	}
+	ret.Offsets = append(ret.Offsets, len(ret.Cells))
+	return ret
+}
+
+// Return indices of neighbours of the i-th cell
+func (a *Adjacency) Neighbors(i int) []int {
+	return a.Cells[a.Offsets[i]:a.Offsets[i+1]]
+}
+
+// Return edge shared by the i-th and j-th cell, nil if they are not
+// neighbours
+func (a *Adjacency) Edge(i, j int) *Edge {
+	for k := a.Offsets[i]; k < a.Offsets[i+1]; k++ {
+		if a.Cells[k] == j {
+			return a.Edges[k]
+		}
+	}
+	return nil
+}
+
+// Return neighbours of every cell as separate slices, which share memory
+// with the adjacency
+func (a *Adjacency) List() [][]int {
+	ret := make([][]int, len(a.Offsets)-1)
+	for i := range ret {
+		ret[i] = a.Neighbors(i)
+	}
+	return ret
+}
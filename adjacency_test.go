@@ -0,0 +1,62 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+
+package voronoi_test
+
+import (
+	. "github.com/pzsz/voronoi"
+	"math/rand"
+	"testing"
+)
+
+func TestCellNeighbors(t *testing.T) {
+	r := rand.New(rand.NewSource(17))
+	sites := make([]Vertex, 200)
+	for i := range sites {
+		sites[i] = Vertex{r.Float64() * 100, r.Float64() * 100}
+	}
+	diagram := ComputeDiagram(sites, NewBBox(0, 100, 0, 100), true)
+	adjacency := diagram.Adjacency()
+
+	// every pair of cells separated by an edge is adjacent both ways
+	pairs := make(map[[2]int]*Edge)
+	for _, edge := range diagram.Edges {
+		if edge.RightCell != nil {
+			pairs[[2]int{edge.LeftCell.Index, edge.RightCell.Index}] = edge
+			pairs[[2]int{edge.RightCell.Index, edge.LeftCell.Index}] = edge
+		}
+	}
+	count := 0
+	for i, cell := range diagram.Cells {
+		neighbors := cell.Neighbors()
+		indices := adjacency.Neighbors(i)
+		if len(neighbors) != len(indices) {
+			t.Fatalf("Cell %d has %d neighbours, adjacency lists %d", i, len(neighbors), len(indices))
+		}
+		for k, other := range neighbors {
+			edge := pairs[[2]int{cell.Index, other.Index}]
+			if edge == nil {
+				t.Errorf("Cell %d is not a neighbour of %d", other.Index, i)
+			}
+			if diagram.Cells[indices[k]] != other {
+				t.Errorf("Adjacency of cell %d lists %d instead of %d", i, indices[k], other.Index)
+			}
+			if cell.SharedEdge(other) != edge || other.SharedEdge(cell) != edge || adjacency.Edge(i, indices[k]) != edge {
+				t.Errorf("Wrong edge between cells %d and %d", i, other.Index)
+			}
+		}
+		count += len(neighbors)
+	}
+	if count != len(pairs) {
+		t.Errorf("Expected %d neighbours in total, got %d", len(pairs), count)
+	}
+
+	list := adjacency.List()
+	if len(list) != len(diagram.Cells) || len(list[7]) != len(diagram.Cells[7].Neighbors()) {
+		t.Errorf("Wrong adjacency list")
+	}
+	if diagram.Cells[0].SharedEdge(diagram.Cells[0]) != nil || adjacency.Edge(0, 0) != nil {
+		t.Errorf("Expected no edge between cell and itself")
+	}
+}
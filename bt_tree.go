@@ -10,6 +10,8 @@ package voronoi
 // https://github.com/fbuihuu/libtree/blob/master/rb.c
 type rbTree struct {
 	root *rbNode
+	// removed nodes, recycled by insertSuccessor
+	junkyard []*rbNode
 }
 
 type rbNodeValue interface {
@@ -28,7 +30,14 @@ type rbNode struct {
 }
 
 func (t *rbTree) insertSuccessor(node *rbNode, vsuccessor rbNodeValue) {
-	successor := &rbNode{value: vsuccessor}
+	var successor *rbNode
+	if n := len(t.junkyard); n > 0 {
+		successor = t.junkyard[n-1]
+		t.junkyard = t.junkyard[:n-1]
+		*successor = rbNode{value: vsuccessor}
+	} else {
+		successor = &rbNode{value: vsuccessor}
+	}
 	vsuccessor.bindToNode(successor)
 
 	var parent *rbNode
@@ -123,6 +132,7 @@ func (t *rbTree) insertSuccessor(node *rbNode, vsuccessor rbNodeValue) {
 }
 
 func (t *rbTree) removeNode(node *rbNode) {
+	t.junkyard = append(t.junkyard, node)
 	// >>> rhill 2011-05-27: Performance: cache previous/next nodes
 	if node.next != nil {
 		node.next.previous = node.previous
@@ -296,6 +306,17 @@ func (t *rbTree) rotateRight(node *rbNode) {
 	q.right = p
 }
 
+// Remove all nodes, keeping them for reuse
+func (t *rbTree) clear() {
+	if t.root == nil {
+		return
+	}
+	for node := t.getFirst(t.root); node != nil; node = node.next {
+		t.junkyard = append(t.junkyard, node)
+	}
+	t.root = nil
+}
+
 func (t *rbTree) getFirst(node *rbNode) *rbNode {
 	for node.left != nil {
 		node = node.left
@@ -13,14 +13,58 @@ import "sort"
 type Cell struct {
 	// Site of the cell
 	Site Vertex
+	// Index of the site in the slice passed to ComputeDiagram. Duplicate
+	// sites share the cell of the first one.
+	Index int
+	// Weight of the site in power diagrams
+	Weight float64
 	// Array of halfedges sorted counterclockwise
 	Halfedges []*Halfedge
+	// Cell extends to infinity, it is set in unbounded diagrams for cells
+	// of sites on the convex hull
+	Unbounded bool
+	// Cell touches the border of the clipping region. The only cell of a
+	// bounded diagram is the whole region, so it always does.
+	OnBorder bool
+	// Cell couldn't be closed, because some of its dangling edges end off
+	// the border of the clipping region. It is set only when cells are
+	// closed, such cells are left with gaps.
+	Open bool
 }
 
-func newCell(site Vertex) *Cell {
-	return &Cell{Site: site}
+func newCell(site Vertex, index int) *Cell {
+	return &Cell{Site: site, Index: index}
 }
 
+// Return cells sharing an edge with this one, counterclockwise
+func (t *Cell) Neighbors() []*Cell {
+	var ret []*Cell
+	for _, halfedge := range t.Halfedges {
+		if other := halfedge.Edge.GetOtherCell(t); other != nil {
+			ret = append(ret, other)
+		}
+	}
+	return ret
+}
+
+// Return edge between this cell and the other one, nil if they are not
+// neighbours
+func (t *Cell) SharedEdge(other *Cell) *Edge {
+	for _, halfedge := range t.Halfedges {
+		if other != nil && halfedge.Edge.GetOtherCell(t) == other {
+			return halfedge.Edge
+		}
+	}
+	return nil
+}
+
+// For sorting cells in order of their sites
+type cellsByIndex []*Cell
+
+func (s cellsByIndex) Len() int           { return len(s) }
+func (s cellsByIndex) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s cellsByIndex) Less(i, j int) bool { return s[i].Index < s[j].Index }
+
 func (t *Cell) prepare() int {
 	halfedges := t.Halfedges
 	iHalfedge := len(halfedges) - 1
@@ -31,7 +75,7 @@ func (t *Cell) prepare() int {
 	for ; iHalfedge >= 0; iHalfedge-- {
 		edge := halfedges[iHalfedge].Edge
 
-		if edge.Vb.Vertex == NO_VERTEX || edge.Va.Vertex == NO_VERTEX {
+		if edge.Kind == SegmentEdge && (edge.Vb.Vertex == NO_VERTEX || edge.Va.Vertex == NO_VERTEX) {
 			halfedges[iHalfedge] = halfedges[len(halfedges)-1]
 			halfedges = halfedges[:len(halfedges)-1]
 		}
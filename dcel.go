@@ -0,0 +1,90 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+// Doubly connected edge list: links between halfedges and vertices
+
+package voronoi
+
+// Tells if the end point of the edge is a vertex of the diagram, rather
+// than infinity or a point on a line
+func isVertex(edge *Edge, v *EdgeVertex) bool {
+	return v.Vertex != NO_VERTEX && edge.Kind != LineEdge
+}
+
+// Link halfedges of the cells to their twins and to the next and previous
+// halfedges around the cells
+func linkHalfedges(cells []*Cell) {
+	for _, cell := range cells {
+		linkCell(cell)
+	}
+}
+
+// Link halfedges of the cell, and their twins back to them
+func linkCell(cell *Cell) {
+	halfedges := cell.Halfedges
+	for k, halfedge := range halfedges {
+		next := halfedges[(k+1)%len(halfedges)]
+		if halfedge.joins(next) {
+			halfedge.Next = next
+			next.Prev = halfedge
+		} else {
+			halfedge.Next = nil
+			next.Prev = nil
+		}
+
+		halfedge.Twin = halfedge.Edge.halfedge(halfedge.Edge.GetOtherCell(cell))
+		if halfedge.Twin != nil {
+			halfedge.Twin.Twin = halfedge
+		}
+	}
+}
+
+// Tells if the next halfedge starts where this one ends, which for
+// infinite ones means that this one goes to infinity and the next one
+// comes from there
+func (h *Halfedge) joins(next *Halfedge) bool {
+	end := h.GetEndVertex()
+	start := next.GetStartVertex()
+	if !isVertex(h.Edge, end) {
+		return !isVertex(next.Edge, start)
+	}
+	return end == start
+}
+
+// Pick halfedge starting in the vertex, preferring one which can't be
+// reached by going to Prev.Twin from the others
+func (v *EdgeVertex) pickHalfedge() {
+	v.Halfedge = nil
+	for _, edge := range v.Edges {
+		for _, halfedge := range [2]*Halfedge{edge.halfedge(edge.LeftCell), edge.halfedge(edge.RightCell)} {
+			if halfedge == nil || halfedge.GetStartVertex() != v {
+				continue
+			}
+			if v.Halfedge == nil || (halfedge.isFirst() && !v.Halfedge.isFirst()) {
+				v.Halfedge = halfedge
+			}
+		}
+	}
+}
+
+// Tells if there's no halfedge before this one around its start vertex
+func (h *Halfedge) isFirst() bool {
+	return h.Twin == nil || h.Twin.Next == nil
+}
+
+// Append cells of the vertex edges to the slice, each one once
+func (v *EdgeVertex) gatherCells(cells []*Cell) []*Cell {
+	start := len(cells)
+	for _, edge := range v.Edges {
+		for _, cell := range [2]*Cell{edge.LeftCell, edge.RightCell} {
+			found := cell == nil
+			for _, other := range cells[start:] {
+				found = found || other == cell
+			}
+			if !found {
+				cells = append(cells, cell)
+			}
+		}
+	}
+	return cells
+}
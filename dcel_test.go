@@ -0,0 +1,212 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+
+package voronoi_test
+
+import (
+	. "github.com/pzsz/voronoi"
+	"math/rand"
+	"testing"
+)
+
+// Verify links between halfedges and vertices. Cells of closed diagrams
+// must have no gaps, and walks around their vertices must visit all
+// halfedges starting in them.
+func verifyLinks(name string, diagram *Diagram, closed bool, t *testing.T) {
+	outgoing := make(map[*EdgeVertex]int)
+	for _, cell := range diagram.Cells {
+		for _, halfedge := range cell.Halfedges {
+			if halfedge.Cell != cell {
+				t.Fatalf("%s: halfedge of cell %d belongs to another one", name, cell.Index)
+			}
+			if start := halfedge.GetStartVertex(); start.Vertex != NO_VERTEX && halfedge.Edge.Kind != LineEdge {
+				outgoing[start]++
+			}
+
+			next := halfedge.Next
+			if next == nil {
+				if closed {
+					t.Errorf("%s: cell %d is not closed", name, cell.Index)
+				}
+			} else if next.Prev != halfedge || next.Cell != cell {
+				t.Errorf("%s: wrong next halfedge in cell %d", name, cell.Index)
+			} else if end := halfedge.GetEndVertex(); end.Vertex != NO_VERTEX && halfedge.Edge.Kind != LineEdge && end != next.GetStartVertex() {
+				t.Errorf("%s: next halfedge in cell %d starts in another vertex", name, cell.Index)
+			}
+
+			other := halfedge.Edge.GetOtherCell(cell)
+			twin := halfedge.Twin
+			if other == nil {
+				if twin != nil {
+					t.Errorf("%s: border halfedge of cell %d has twin", name, cell.Index)
+				}
+			} else if twin == nil || twin.Twin != halfedge || twin.Edge != halfedge.Edge || twin.Cell != other {
+				t.Errorf("%s: wrong twin of halfedge between cells %d and %d", name, cell.Index, other.Index)
+			} else if twin.GetStartVertex() != halfedge.GetEndVertex() || twin.GetEndVertex() != halfedge.GetStartVertex() {
+				t.Errorf("%s: twin of halfedge between cells %d and %d goes another way", name, cell.Index, other.Index)
+			}
+		}
+	}
+
+	for _, edge := range diagram.Edges {
+		for _, v := range []*EdgeVertex{edge.Va, edge.Vb} {
+			if v.Vertex == NO_VERTEX || edge.Kind == LineEdge {
+				continue
+			}
+			found := false
+			for _, e := range v.Edges {
+				found = found || e == edge
+			}
+			if !found {
+				t.Errorf("%s: vertex %v misses its edge", name, v.Vertex)
+			}
+			if v.Halfedge == nil || v.Halfedge.GetStartVertex() != v {
+				t.Errorf("%s: wrong halfedge of vertex %v", name, v.Vertex)
+				continue
+			}
+			if !closed {
+				continue
+			}
+			n := 0
+			for h := v.Halfedge; h != nil && n <= outgoing[v]; {
+				n++
+				if h.Prev == nil || h.Prev.Twin == nil || h.Prev.Twin == v.Halfedge {
+					break
+				}
+				h = h.Prev.Twin
+			}
+			if n != outgoing[v] {
+				t.Errorf("%s: walk around vertex %v visits %d of %d halfedges", name, v.Vertex, n, outgoing[v])
+			}
+		}
+	}
+}
+
+func TestHalfedgeLinks(t *testing.T) {
+	r := rand.New(rand.NewSource(15))
+	sites := make([]Vertex, 300)
+	for i := range sites {
+		sites[i] = Vertex{r.Float64() * 100, r.Float64() * 100}
+	}
+	bbox := NewBBox(0, 100, 0, 100)
+
+	verifyLinks("closed", ComputeDiagram(sites, bbox, true), true, t)
+	verifyLinks("open", ComputeDiagram(sites, bbox, false), false, t)
+
+	diagram, err := ComputeDiagramWithOptions(sites, Options{Unbounded: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyLinks("unbounded", diagram, true, t)
+
+	region, err := NewConvexPolygon([]Vertex{Vertex{50, 0}, Vertex{100, 30}, Vertex{90, 100}, Vertex{10, 90}, Vertex{0, 20}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	diagram, err = ComputeDiagramWithOptions(sites, Options{Region: region, CloseCells: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyLinks("region", diagram, true, t)
+
+	weighted := make([]WeightedSite, len(sites))
+	for i, site := range sites {
+		weighted[i] = WeightedSite{site, r.Float64() * 20}
+	}
+	diagram, err = ComputePowerDiagram(weighted, Options{BBox: bbox, CloseCells: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyLinks("power", diagram, true, t)
+
+	// three edges meet in every vertex of random sites, apart from
+	// corners of the bounding box
+	diagram = ComputeDiagram(sites, bbox, true)
+	for _, edge := range diagram.Edges {
+		corner := (edge.Va.X == 0 || edge.Va.X == 100) && (edge.Va.Y == 0 || edge.Va.Y == 100)
+		if n := len(edge.Va.Edges); (corner && n != 2) || (!corner && n != 3) {
+			t.Errorf("Wrong number of edges of vertex %v: %d", edge.Va.Vertex, n)
+		}
+	}
+}
+
+// Verify that vertices are listed once, by their indices, and know their
+// edges and cells
+func verifyVertices(name string, diagram *Diagram, t *testing.T) {
+	seen := make(map[Vertex]bool)
+	for i, v := range diagram.Vertices {
+		if v.Index != i {
+			t.Errorf("%s: vertex %d has index %d", name, i, v.Index)
+		}
+		if seen[v.Vertex] {
+			t.Errorf("%s: vertex %v listed twice", name, v.Vertex)
+		}
+		seen[v.Vertex] = true
+
+		cells := make(map[*Cell]bool)
+		for _, edge := range v.Edges {
+			if edge.Va != v && edge.Vb != v {
+				t.Errorf("%s: edge of vertex %d doesn't end in it", name, i)
+			}
+			for _, cell := range []*Cell{edge.LeftCell, edge.RightCell} {
+				if cell != nil {
+					cells[cell] = true
+				}
+			}
+		}
+		if len(v.Cells) != len(cells) {
+			t.Errorf("%s: vertex %d has %d cells instead of %d", name, i, len(v.Cells), len(cells))
+		}
+		for _, cell := range v.Cells {
+			if !cells[cell] {
+				t.Errorf("%s: cell %d doesn't touch vertex %d", name, cell.Index, i)
+			}
+		}
+	}
+
+	for _, edge := range diagram.Edges {
+		for _, v := range []*EdgeVertex{edge.Va, edge.Vb} {
+			if v.Vertex == NO_VERTEX || edge.Kind == LineEdge {
+				if v.Index != -1 {
+					t.Errorf("%s: end of infinite edge has index %d", name, v.Index)
+				}
+			} else if v.Index < 0 || v.Index >= len(diagram.Vertices) || diagram.Vertices[v.Index] != v {
+				t.Errorf("%s: vertex %v is not listed", name, v.Vertex)
+			}
+		}
+	}
+}
+
+func TestDiagramVertices(t *testing.T) {
+	r := rand.New(rand.NewSource(16))
+	sites := make([]Vertex, 300)
+	for i := range sites {
+		sites[i] = Vertex{r.Float64() * 100, r.Float64() * 100}
+	}
+	bbox := NewBBox(0, 100, 0, 100)
+
+	// Euler's formula for the bounding box split into cells
+	diagram := ComputeDiagram(sites, bbox, true)
+	verifyVertices("closed", diagram, t)
+	if n := len(diagram.Vertices) - len(diagram.Edges) + len(diagram.Cells); n != 1 {
+		t.Errorf("Expected V - E + F = 1, got %d", n)
+	}
+
+	verifyVertices("open", ComputeDiagram(sites, bbox, false), t)
+	verifyVertices("lattice", ComputeDiagram(lattice(10, 0, 1), NewBBox(-0.5, 9.5, -0.5, 9.5), true), t)
+	diagram, err := ComputeDiagramWithOptions(sites, Options{Unbounded: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyVertices("unbounded", diagram, t)
+
+	// vertices are numbered the same way every time
+	a := ComputeDiagram(sites, bbox, true)
+	b := NewVoronoi().Compute(sites, bbox, true)
+	for i := range a.Vertices {
+		if a.Vertices[i].Vertex != b.Vertices[i].Vertex {
+			t.Fatalf("Vertex %d differs between computations", i)
+		}
+	}
+}
@@ -0,0 +1,578 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+// Voronoi diagrams updated by inserting and removing sites
+
+package voronoi
+
+import "sort"
+
+// Voronoi diagram which can be updated by inserting and removing sites.
+// Only cells around the inserted or removed site are recomputed, with the
+// same result as computing the diagram of the remaining sites again. Cells
+// are computed by clipping the region, like ComputePowerDiagram does, so
+// there are no Delaunay triangles, though Triangulation().Edges lists
+// neighbouring sites.
+type DynamicDiagram struct {
+	diagram    *Diagram
+	region     *ConvexPolygon
+	closeCells bool
+
+	// polygon of every cell by site index, nil for removed sites
+	polygons []*powerPolygon
+	// index of every site, to reject duplicates
+	sites map[Vertex]int
+	// indices of sites whose cells have no area
+	emptyCells map[int]bool
+	// edges between pairs of cells, by indices of their sites
+	edges map[[2]int]*Edge
+	// position of every edge in diagram.Edges
+	edgeIndex map[*Edge]int
+	// position of every pair of neighbours in diagram.delaunayEdges
+	pairIndex map[[2]int]int
+	// vertices whose edges or halfedges changed
+	touched map[*EdgeVertex]bool
+	// cell the point location starts from
+	last int
+}
+
+// Create dynamic diagram of the sites, clipped to opts.BBox or opts.Region.
+// Sites get indices in the order they are given, the ones inserted later
+// get the next indices. Sites must not repeat, repeated ones are rejected
+// with ErrDuplicateSite rather than sharing a cell like ComputeDiagram does.
+func NewDynamicDiagram(sites []Vertex, opts Options) (*DynamicDiagram, error) {
+	if opts.Unbounded {
+		return nil, ErrUnbounded
+	}
+	region := opts.BBox.Polygon()
+	if opts.Region != nil {
+		var err error
+		if region, err = validateRegion(opts.Region); err != nil {
+			return nil, err
+		}
+	} else if err := validateBBox(opts.BBox); err != nil {
+		return nil, err
+	}
+	if !isFinite(opts.Tolerance) || opts.Tolerance < 0 {
+		return nil, ErrInvalidTolerance
+	}
+
+	d := &DynamicDiagram{
+		diagram:    &Diagram{epsilon: opts.epsilon(nil), region: region},
+		region:     region,
+		closeCells: opts.CloseCells,
+		sites:      make(map[Vertex]int),
+		emptyCells: make(map[int]bool),
+		edges:      make(map[[2]int]*Edge),
+		edgeIndex:  make(map[*Edge]int),
+		pairIndex:  make(map[[2]int]int),
+		touched:    make(map[*EdgeVertex]bool),
+	}
+	for _, site := range sites {
+		if _, _, err := d.Insert(site); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// Return the diagram, which is updated in place by Insert and Remove.
+// Cells of removed sites are missing from Cells, and CellForSite returns
+// nil for them. Vertices keep their indices until they are removed, when
+// the last one takes the index over.
+func (d *DynamicDiagram) Diagram() *Diagram {
+	return d.diagram
+}
+
+// Insert new site. Returns its cell and cells of other sites which changed,
+// or ErrDuplicateSite if the site is already in the diagram.
+func (d *DynamicDiagram) Insert(site Vertex) (*Cell, []*Cell, error) {
+	index := len(d.polygons)
+	if !isFinite(site.X) || !isFinite(site.Y) {
+		return nil, nil, &SiteError{index, site}
+	}
+	if _, ok := d.sites[site]; ok {
+		return nil, nil, ErrDuplicateSite
+	}
+
+	// find cells losing area to the new one, they are all connected
+	affected := make(map[int]bool)
+	var queue []int
+	if start := d.locate(site); start >= 0 && d.losesTo(start, site) {
+		queue = append(queue, start)
+	} else {
+		// site outside of the region, check every cell
+		for i, polygon := range d.polygons {
+			if polygon != nil && d.losesTo(i, site) {
+				queue = append(queue, i)
+			}
+		}
+	}
+	for _, i := range queue {
+		affected[i] = true
+	}
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		for _, j := range d.polygons[i].sides {
+			if j >= 0 && !affected[j] && d.losesTo(j, site) {
+				affected[j] = true
+				queue = append(queue, j)
+			}
+		}
+	}
+
+	cell := newCell(site, index)
+	d.sites[site] = index
+	d.polygons = append(d.polygons, nil)
+	d.diagram.siteCells = append(d.diagram.siteCells, cell)
+	d.diagram.Cells = append(d.diagram.Cells, cell)
+
+	// a cell losing area to the site has some vertex closer to it, so
+	// when none does, the site gets no area and nothing changes. The
+	// region is covered by cells of other sites, if there are any.
+	empty := len(affected) == 0 && len(d.sites) > 1
+	affected[index] = true
+	polygons := d.recompute(affected, -1, func(i int, candidates []int) *powerPolygon {
+		if i == index {
+			if empty {
+				return &powerPolygon{}
+			}
+			return d.cellPolygon(i, candidates)
+		}
+		polygon := d.polygons[i].clone()
+		polygon.clipBisector(d.diagram.siteCells[i].Site, site, index, d.diagram.epsilon)
+		return polygon
+	})
+	return cell, d.apply(polygons, affected, index, -1), nil
+}
+
+// Remove site of given index. Returns cells of other sites which changed.
+func (d *DynamicDiagram) Remove(index int) ([]*Cell, error) {
+	if index < 0 || index >= len(d.polygons) || d.polygons[index] == nil {
+		return nil, ErrNoSuchSite
+	}
+
+	// neighbours take over the cell, their new neighbours are among their
+	// old ones and the ones of the removed site
+	affected := make(map[int]bool)
+	for _, j := range d.polygons[index].sides {
+		if j >= 0 {
+			affected[j] = true
+		}
+	}
+	// sites without area, like the ones outside of the region, can get
+	// part of the removed cell too, and nothing outside of it
+	removed := d.polygons[index]
+	empty := make(map[int]bool)
+	for j := range d.emptyCells {
+		if j != index {
+			affected[j] = true
+			empty[j] = true
+		}
+	}
+	polygons := d.recompute(affected, index, func(i int, candidates []int) *powerPolygon {
+		if !empty[i] {
+			return d.cellPolygon(i, candidates)
+		}
+		site := d.diagram.siteCells[i].Site
+		polygon := removed.clone()
+		for _, j := range candidates {
+			polygon.clipBisector(site, d.diagram.siteCells[j].Site, j, d.diagram.epsilon)
+		}
+		return polygon
+	})
+	for i := range empty {
+		if len(polygons[i].vertices) == 0 {
+			delete(affected, i)
+			delete(polygons, i)
+		}
+	}
+
+	changed := d.apply(polygons, affected, -1, index)
+	cell := d.diagram.siteCells[index]
+	for _, halfedge := range cell.Halfedges {
+		d.removeEdge(halfedge.Edge)
+	}
+	d.refreshVertices()
+	cell.Halfedges = nil
+
+	delete(d.sites, cell.Site)
+	delete(d.emptyCells, index)
+	d.polygons[index] = nil
+	d.diagram.siteCells[index] = nil
+	cells := d.diagram.Cells
+	i := sort.Search(len(cells), func(i int) bool { return cells[i].Index >= index })
+	d.diagram.Cells = append(cells[:i], cells[i+1:]...)
+	return changed, nil
+}
+
+// Find the cell containing the vertex by walking to neighbours closer to
+// it. Returns -1 if no cell has any area.
+func (d *DynamicDiagram) locate(v Vertex) int {
+	i := d.last
+	if i >= len(d.polygons) || d.polygons[i] == nil || len(d.polygons[i].vertices) == 0 {
+		i = -1
+		for j, polygon := range d.polygons {
+			if polygon != nil && len(polygon.vertices) > 0 {
+				i = j
+				break
+			}
+		}
+		if i < 0 {
+			return -1
+		}
+	}
+
+	// distance to sites strictly decreases, so the walk ends in the cell
+	// whose site is the closest one, unless the vertex lies outside of
+	// the region
+	for {
+		next := i
+		dist := distance2(v, d.diagram.siteCells[i].Site)
+		for _, j := range d.polygons[i].sides {
+			if j < 0 {
+				continue
+			}
+			if jDist := distance2(v, d.diagram.siteCells[j].Site); jDist < dist {
+				next, dist = j, jDist
+			}
+		}
+		if next == i {
+			d.last = i
+			return i
+		}
+		i = next
+	}
+}
+
+// Check if part of the i-th cell is closer to the site
+func (d *DynamicDiagram) losesTo(i int, site Vertex) bool {
+	own := d.diagram.siteCells[i].Site
+	for _, v := range d.polygons[i].vertices {
+		if distance2(v, site) < distance2(v, own) {
+			return true
+		}
+	}
+	return false
+}
+
+// Polygon of the i-th cell, clipped by bisectors with candidate neighbours
+func (d *DynamicDiagram) cellPolygon(i int, candidates []int) *powerPolygon {
+	site := d.diagram.siteCells[i].Site
+	polygon := regionPolygon(d.region)
+	for _, j := range candidates {
+		polygon.clipBisector(site, d.diagram.siteCells[j].Site, j, d.diagram.epsilon)
+	}
+	return polygon
+}
+
+// Compute new polygons of the affected cells. Cells whose edges with the
+// affected ones would change are added to them, which only happens due
+// to rounding errors.
+func (d *DynamicDiagram) recompute(affected map[int]bool, removed int, polygon func(i int, candidates []int) *powerPolygon) map[int]*powerPolygon {
+	for {
+		polygons := make(map[int]*powerPolygon, len(affected))
+		for _, i := range sortedIndices(affected) {
+			// every cell may neighbour the affected ones and their
+			// neighbours, apart from the removed one
+			candidates := make(map[int]bool)
+			for j := range affected {
+				candidates[j] = true
+			}
+			for _, k := range []int{i, removed} {
+				if k >= 0 && k < len(d.polygons) && d.polygons[k] != nil {
+					for _, j := range d.polygons[k].sides {
+						if j >= 0 {
+							candidates[j] = true
+						}
+					}
+				}
+			}
+			delete(candidates, i)
+			delete(candidates, removed)
+			polygons[i] = polygon(i, sortedIndices(candidates))
+		}
+
+		grown := false
+		for _, i := range sortedIndices(affected) {
+			for _, j := range d.mismatches(i, polygons[i], affected, removed) {
+				affected[j] = true
+				grown = true
+			}
+		}
+		if !grown {
+			return polygons
+		}
+	}
+}
+
+// Return unaffected neighbours of the i-th cell whose edge with it would
+// change with the new polygon
+func (d *DynamicDiagram) mismatches(i int, polygon *powerPolygon, affected map[int]bool, removed int) []int {
+	eps := d.diagram.epsilon
+	var ret []int
+	seen := make(map[int]bool)
+	check := func(j int) {
+		if j < 0 || j == removed || affected[j] || seen[j] {
+			return
+		}
+		seen[j] = true
+
+		edge := d.edges[pairKey(i, j)]
+		n := len(polygon.vertices)
+		for k, side := range polygon.sides {
+			if side != j {
+				continue
+			}
+			va := polygon.vertices[k]
+			vb := polygon.vertices[(k+1)%n]
+			if edge != nil && ((equalVertices(va, edge.Va.Vertex, eps) && equalVertices(vb, edge.Vb.Vertex, eps)) ||
+				(equalVertices(va, edge.Vb.Vertex, eps) && equalVertices(vb, edge.Va.Vertex, eps))) {
+				return
+			}
+			ret = append(ret, j)
+			return
+		}
+		if edge != nil {
+			// edge disappears
+			ret = append(ret, j)
+		}
+	}
+
+	for _, j := range polygon.sides {
+		check(j)
+	}
+	if d.polygons[i] != nil {
+		for _, j := range d.polygons[i].sides {
+			check(j)
+		}
+	}
+	return ret
+}
+
+// Replace edges of the affected cells with ones along their new polygons.
+// Returns cells which changed, apart from the inserted and removed one.
+func (d *DynamicDiagram) apply(polygons map[int]*powerPolygon, affected map[int]bool, inserted, removed int) []*Cell {
+	cells := d.diagram.siteCells
+	indices := sortedIndices(affected)
+
+	// edges shared with unaffected neighbours stay the same
+	for _, i := range indices {
+		cell := cells[i]
+		for _, halfedge := range cell.Halfedges {
+			edge := halfedge.Edge
+			other := edge.GetOtherCell(cell)
+			if other == nil || affected[other.Index] || other.Index == removed {
+				d.removeEdge(edge)
+			}
+		}
+		cell.Halfedges = cell.Halfedges[:0]
+	}
+
+	var changed []*Cell
+	for _, i := range indices {
+		cell := cells[i]
+		polygon := polygons[i]
+		d.polygons[i] = polygon
+		if len(polygon.vertices) == 0 {
+			d.emptyCells[i] = true
+		} else {
+			delete(d.emptyCells, i)
+		}
+		if i != inserted {
+			changed = append(changed, cell)
+		}
+
+		vertices := d.sharedVertices(i, polygon)
+		n := len(polygon.vertices)
+		cell.OnBorder = false
+		for k, j := range polygon.sides {
+			va := vertices[k]
+			vb := vertices[(k+1)%n]
+			if j < 0 {
+				cell.OnBorder = true
+				if d.closeCells {
+					edge := newEdge(cell, nil)
+					edge.Border = true
+					edge.BorderSide = -1 - j
+					d.addEdge(edge, va, vb)
+					cell.Halfedges = append(cell.Halfedges, newHalfedge(edge, cell, nil))
+				}
+				continue
+			}
+
+			// whichever cell of the pair comes first creates the edge
+			key := pairKey(i, j)
+			edge := d.edges[key]
+			if edge == nil {
+				edge = newEdge(cell, cells[j])
+				d.edges[key] = edge
+				d.addEdge(edge, va, vb)
+			}
+			cell.Halfedges = append(cell.Halfedges, newHalfedge(edge, cell, cells[j]))
+			d.touched[edge.Va] = true
+			d.touched[edge.Vb] = true
+		}
+		cell.prepare()
+	}
+
+	// twins of the new halfedges can be in any of the cells
+	for _, i := range indices {
+		linkCell(cells[i])
+	}
+	d.refreshVertices()
+	return changed
+}
+
+// Vertices of the polygon of the i-th cell, shared with the edges of
+// the cell which already exist where possible
+func (d *DynamicDiagram) sharedVertices(i int, polygon *powerPolygon) []*EdgeVertex {
+	n := len(polygon.vertices)
+	ret := make([]*EdgeVertex, n)
+	for k, j := range polygon.sides {
+		if j < 0 || d.edges[pairKey(i, j)] == nil {
+			continue
+		}
+		edge := d.edges[pairKey(i, j)]
+		for _, l := range [2]int{k, (k + 1) % n} {
+			for _, v := range [2]*EdgeVertex{edge.Va, edge.Vb} {
+				if ret[l] == nil && equalVertices(polygon.vertices[l], v.Vertex, d.diagram.epsilon) {
+					ret[l] = v
+				}
+			}
+		}
+	}
+	for k, v := range polygon.vertices {
+		if ret[k] == nil {
+			ret[k] = &EdgeVertex{Vertex: v, Index: -1}
+		}
+	}
+	return ret
+}
+
+func (d *DynamicDiagram) addEdge(edge *Edge, va, vb *EdgeVertex) {
+	d.edgeIndex[edge] = len(d.diagram.Edges)
+	d.diagram.Edges = append(d.diagram.Edges, edge)
+	edge.Va = va
+	edge.Vb = vb
+	if edge.RightCell != nil {
+		d.pairIndex[pairKey(edge.LeftCell.Index, edge.RightCell.Index)] = len(d.diagram.delaunayEdges)
+		d.diagram.delaunayEdges = append(d.diagram.delaunayEdges, [2]*Cell{edge.LeftCell, edge.RightCell})
+	}
+	for _, v := range [2]*EdgeVertex{va, vb} {
+		v.Edges = append(v.Edges, edge)
+		d.touched[v] = true
+	}
+}
+
+func (d *DynamicDiagram) removeEdge(edge *Edge) {
+	i, ok := d.edgeIndex[edge]
+	if !ok {
+		return
+	}
+	edges := d.diagram.Edges
+	last := edges[len(edges)-1]
+	edges[i] = last
+	d.edgeIndex[last] = i
+	d.diagram.Edges = edges[:len(edges)-1]
+	delete(d.edgeIndex, edge)
+	if edge.RightCell != nil {
+		key := pairKey(edge.LeftCell.Index, edge.RightCell.Index)
+		delete(d.edges, key)
+
+		pairs := d.diagram.delaunayEdges
+		last := pairs[len(pairs)-1]
+		pairs[d.pairIndex[key]] = last
+		d.pairIndex[pairKey(last[0].Index, last[1].Index)] = d.pairIndex[key]
+		d.diagram.delaunayEdges = pairs[:len(pairs)-1]
+		delete(d.pairIndex, key)
+	}
+
+	for _, v := range [2]*EdgeVertex{edge.Va, edge.Vb} {
+		for k, e := range v.Edges {
+			if e == edge {
+				v.Edges = append(v.Edges[:k], v.Edges[k+1:]...)
+				break
+			}
+		}
+		d.touched[v] = true
+	}
+}
+
+// Update vertices touched since the last refresh, in order of their
+// coordinates so that new ones get the same indices every time. Vertices
+// without edges are removed, the last one takes place of each of them.
+func (d *DynamicDiagram) refreshVertices() {
+	touched := make([]*EdgeVertex, 0, len(d.touched))
+	for v := range d.touched {
+		delete(d.touched, v)
+		touched = append(touched, v)
+	}
+	sort.Slice(touched, func(i, j int) bool {
+		a, b := touched[i].Vertex, touched[j].Vertex
+		return a.Y < b.Y || (a.Y == b.Y && a.X < b.X)
+	})
+
+	for _, v := range touched {
+		vertices := d.diagram.Vertices
+		if len(v.Edges) == 0 {
+			if v.Index >= 0 {
+				last := vertices[len(vertices)-1]
+				vertices[v.Index] = last
+				last.Index = v.Index
+				d.diagram.Vertices = vertices[:len(vertices)-1]
+				v.Index = -1
+			}
+			continue
+		}
+		if v.Index < 0 {
+			v.Index = len(vertices)
+			d.diagram.Vertices = append(vertices, v)
+		}
+		v.pickHalfedge()
+		v.Cells = v.gatherCells(v.Cells[:0])
+	}
+}
+
+func (p *powerPolygon) clone() *powerPolygon {
+	return &powerPolygon{
+		vertices: append([]Vertex(nil), p.vertices...),
+		sides:    append([]int(nil), p.sides...),
+	}
+}
+
+// Keep part of the polygon closer to site than to other site, new side
+// lies on their bisector
+func (p *powerPolygon) clipBisector(site, other Vertex, j int, epsilon float64) {
+	n := Vertex{other.X - site.X, other.Y - site.Y}
+	// bisector passes through the midpoint, which is more accurate than
+	// the difference of squared lengths far from the origin
+	p.clip(n, n.X*(site.X+other.X)/2+n.Y*(site.Y+other.Y)/2, j, epsilon)
+}
+
+func pairKey(i, j int) [2]int {
+	if j < i {
+		return [2]int{j, i}
+	}
+	return [2]int{i, j}
+}
+
+func sortedIndices(set map[int]bool) []int {
+	ret := make([]int, 0, len(set))
+	for i := range set {
+		ret = append(ret, i)
+	}
+	sort.Ints(ret)
+	return ret
+}
+
+func distance2(a, b Vertex) float64 {
+	dx := a.X - b.X
+	dy := a.Y - b.Y
+	return dx*dx + dy*dy
+}
+
+func equalVertices(a, b Vertex, epsilon float64) bool {
+	return equalWithEpsilon(a.X, b.X, epsilon) && equalWithEpsilon(a.Y, b.Y, epsilon)
+}
@@ -0,0 +1,268 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+
+package voronoi_test
+
+import (
+	. "github.com/pzsz/voronoi"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// Vertices of the cell
+func cellVertices(cell *Cell) []Vertex {
+	ret := make([]Vertex, 0, len(cell.Halfedges))
+	for _, halfedge := range cell.Halfedges {
+		ret = append(ret, halfedge.GetStartpoint())
+	}
+	return ret
+}
+
+// Compare vertices of cells regardless of where their halfedges start
+func sameVertices(a, b []Vertex) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	used := make([]bool, len(b))
+	for _, va := range a {
+		found := false
+		for j, vb := range b {
+			if !used[j] && math.Abs(va.X-vb.X) < 1e-7 && math.Abs(va.Y-vb.Y) < 1e-7 {
+				used[j] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// Pair of sites in the same order regardless of the order of arguments
+func sitePair(a, b Vertex) [2]Vertex {
+	if b.Y < a.Y || (b.Y == a.Y && b.X < a.X) {
+		return [2]Vertex{b, a}
+	}
+	return [2]Vertex{a, b}
+}
+
+func TestDynamicDiagram(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	bbox := NewBBox(0, 100, 0, 100)
+	randomSite := func() Vertex {
+		return Vertex{r.Float64() * 100, r.Float64() * 100}
+	}
+
+	var sites []Vertex
+	for i := 0; i < 50; i++ {
+		sites = append(sites, randomSite())
+	}
+	dynamic, err := NewDynamicDiagram(sites, Options{BBox: bbox, CloseCells: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	alive := make([]bool, len(sites))
+	for i := range alive {
+		alive[i] = true
+	}
+
+	for step := 0; step < 200; step++ {
+		diagram := dynamic.Diagram()
+		before := make(map[*Cell][]Vertex)
+		for _, cell := range diagram.Cells {
+			before[cell] = cellVertices(cell)
+		}
+
+		var changed []*Cell
+		if r.Intn(3) == 0 {
+			i := r.Intn(len(sites))
+			changed, err = dynamic.Remove(i)
+			if !alive[i] {
+				if err != ErrNoSuchSite {
+					t.Fatalf("Expected ErrNoSuchSite, got %v", err)
+				}
+				continue
+			}
+			alive[i] = false
+		} else {
+			site := randomSite()
+			var cell *Cell
+			cell, changed, err = dynamic.Insert(site)
+			if cell.Index != len(sites) || cell.Site != site {
+				t.Fatalf("Wrong cell of inserted site %v", cell)
+			}
+			sites = append(sites, site)
+			alive = append(alive, true)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// cells which weren't reported stay the same
+		reported := make(map[*Cell]bool)
+		for _, cell := range changed {
+			reported[cell] = true
+		}
+		for _, cell := range diagram.Cells {
+			if old, ok := before[cell]; ok && !reported[cell] && !sameVertices(old, cellVertices(cell)) {
+				t.Errorf("Step %d: cell %d changed without notice", step, cell.Index)
+			}
+		}
+
+		// compare with diagram of remaining sites computed from scratch
+		var remaining []Vertex
+		var indices []int
+		for i, site := range sites {
+			if alive[i] {
+				remaining = append(remaining, site)
+				indices = append(indices, i)
+			}
+		}
+		verifyLinks("dynamic", diagram, true, t)
+		verifyVertices("dynamic", diagram, t)
+		expected := ComputeDiagram(remaining, bbox, true)
+		if len(diagram.Cells) != len(expected.Cells) || len(diagram.Edges) != len(expected.Edges) {
+			t.Fatalf("Step %d: expected %d cells and %d edges, got %d and %d", step,
+				len(expected.Cells), len(expected.Edges), len(diagram.Cells), len(diagram.Edges))
+		}
+		for k, i := range indices {
+			cell := diagram.CellForSite(i)
+			if cell == nil || cell.Index != i {
+				t.Fatalf("Step %d: wrong cell of site %d", step, i)
+			}
+			if !sameVertices(cellVertices(cell), cellVertices(expected.Cells[k])) {
+				t.Errorf("Step %d: cell %d differs from full computation", step, i)
+			}
+		}
+
+		// neighbouring sites are the ones whose cells share an edge
+		// in the full computation
+		pairs := make(map[[2]Vertex]bool)
+		for _, edge := range expected.Edges {
+			if edge.RightCell != nil {
+				pairs[sitePair(edge.LeftCell.Site, edge.RightCell.Site)] = true
+			}
+		}
+		triangulation := diagram.Triangulation()
+		if len(triangulation.Triangles) != 0 || len(triangulation.Edges) != len(pairs) {
+			t.Fatalf("Step %d: expected no triangles and %d edges, got %d and %d", step,
+				len(pairs), len(triangulation.Triangles), len(triangulation.Edges))
+		}
+		for _, edge := range triangulation.Edges {
+			if !pairs[sitePair(triangulation.Cells[edge[0]].Site, triangulation.Cells[edge[1]].Site)] {
+				t.Errorf("Step %d: sites %d and %d aren't neighbours", step, edge[0], edge[1])
+			}
+		}
+	}
+
+	if _, _, err := dynamic.Insert(sites[len(sites)-1]); err != ErrDuplicateSite {
+		t.Errorf("Expected ErrDuplicateSite, got %v", err)
+	}
+}
+
+// Sites outside of the region may have no area, until the sites which
+// cover it are removed
+func TestDynamicSitesOutsideRegion(t *testing.T) {
+	bbox := NewBBox(0, 10, 0, 10)
+	cellArea := func(cell *Cell) float64 {
+		area := 0.0
+		for _, halfedge := range cell.Halfedges {
+			s := halfedge.GetStartpoint()
+			e := halfedge.GetEndpoint()
+			area -= (s.X*e.Y - e.X*s.Y) / 2
+		}
+		return area
+	}
+	expectAreas := func(name string, diagram *Diagram, expected []float64) {
+		if len(diagram.Cells) != len(expected) {
+			t.Fatalf("%s: expected %d cells, got %d", name, len(expected), len(diagram.Cells))
+		}
+		for i, cell := range diagram.Cells {
+			if area := cellArea(cell); math.Abs(area-expected[i]) > 1e-9 {
+				t.Errorf("%s: expected area %v of cell %d, got %v", name, expected[i], cell.Index, area)
+			}
+		}
+	}
+
+	dynamic, err := NewDynamicDiagram([]Vertex{Vertex{5, 0.5}, Vertex{5, -2}, Vertex{5, 9}}, Options{BBox: bbox, CloseCells: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectAreas("insert", dynamic.Diagram(), []float64{47.5, 0, 52.5})
+
+	dynamic, err = NewDynamicDiagram([]Vertex{Vertex{5, -2}, Vertex{5, 0.5}, Vertex{5, 9}}, Options{BBox: bbox, CloseCells: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dynamic.Remove(1); err != nil {
+		t.Fatal(err)
+	}
+	expectAreas("remove", dynamic.Diagram(), []float64{35, 65})
+
+	// random sites around the region, compared with power diagram of
+	// sites without weights computed from scratch
+	r := rand.New(rand.NewSource(11))
+	randomSite := func() Vertex {
+		return Vertex{r.Float64()*30 - 10, r.Float64()*30 - 10}
+	}
+	var sites []Vertex
+	for i := 0; i < 20; i++ {
+		sites = append(sites, randomSite())
+	}
+	dynamic, err = NewDynamicDiagram(sites, Options{BBox: bbox, CloseCells: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	alive := make([]bool, len(sites))
+	for i := range alive {
+		alive[i] = true
+	}
+	for step := 0; step < 200; step++ {
+		if i := r.Intn(len(sites)); r.Intn(2) == 0 && alive[i] {
+			if _, err := dynamic.Remove(i); err != nil {
+				t.Fatal(err)
+			}
+			alive[i] = false
+		} else {
+			site := randomSite()
+			if _, _, err := dynamic.Insert(site); err != nil {
+				t.Fatal(err)
+			}
+			sites = append(sites, site)
+			alive = append(alive, true)
+		}
+
+		var weighted []WeightedSite
+		var indices []int
+		for i, site := range sites {
+			if alive[i] {
+				weighted = append(weighted, WeightedSite{site, 0})
+				indices = append(indices, i)
+			}
+		}
+		if len(weighted) == 0 {
+			continue
+		}
+		expected, err := ComputePowerDiagram(weighted, Options{BBox: bbox, CloseCells: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		diagram := dynamic.Diagram()
+		total := 0.0
+		for k, i := range indices {
+			cell := diagram.CellForSite(i)
+			total += cellArea(cell)
+			if !sameVertices(cellVertices(cell), cellVertices(expected.Cells[k])) {
+				t.Errorf("Step %d: cell %d differs from full computation", step, i)
+			}
+		}
+		if math.Abs(total-100) > 1e-6 {
+			t.Errorf("Step %d: cells cover area %v", step, total)
+		}
+		verifyLinks("outside", diagram, true, t)
+	}
+}
@@ -0,0 +1,92 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+// Errors reported by ComputeDiagramE and ComputeDiagramWithOptions
+
+package voronoi
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Returned when there are no sites to compute diagram for
+var ErrNoSites = errors.New("voronoi: no sites")
+
+// Returned for clipping regions which are not strictly convex polygons
+var ErrNotConvex = errors.New("voronoi: region is not a convex polygon")
+
+// Returned for clip polygons with rings of less than three vertices or
+// with NaN or infinite coordinates
+var ErrInvalidPolygon = errors.New("voronoi: invalid polygon")
+
+// Returned when unbounded diagram is requested where it is not supported
+var ErrUnbounded = errors.New("voronoi: unbounded diagram not supported")
+
+// Returned for negative, NaN or infinite Options.Tolerance
+var ErrInvalidTolerance = errors.New("voronoi: invalid tolerance")
+
+// Returned when reading GeoJSON which is not a FeatureCollection or has
+// points without two coordinates
+var ErrInvalidGeoJSON = errors.New("voronoi: invalid GeoJSON")
+
+// Returned when inserting a site which is already in a dynamic diagram
+var ErrDuplicateSite = errors.New("voronoi: duplicate site")
+
+// Returned when removing a site which is not in a dynamic diagram
+var ErrNoSuchSite = errors.New("voronoi: no such site")
+
+// Bounding box is inverted or some of its coordinates are NaN or infinite
+type BBoxError struct {
+	BBox BBox
+}
+
+func (e *BBoxError) Error() string {
+	return fmt.Sprintf("voronoi: invalid bounding box %v", e.BBox)
+}
+
+// Site has NaN or infinite coordinates
+type SiteError struct {
+	// Index of the site in the input slice
+	Index int
+	Site  Vertex
+}
+
+func (e *SiteError) Error() string {
+	return fmt.Sprintf("voronoi: invalid site %d %v", e.Index, e.Site)
+}
+
+// Sweep reached a site it has no cell for. This is an internal
+// error, ComputeDiagram panics with it.
+type CellNotFoundError struct {
+	Site Vertex
+}
+
+func (e *CellNotFoundError) Error() string {
+	return fmt.Sprintf("voronoi: couldn't find cell for site %v", e.Site)
+}
+
+func isFinite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+func validateBBox(bbox BBox) error {
+	if !isFinite(bbox.Xl) || !isFinite(bbox.Xr) || !isFinite(bbox.Yt) || !isFinite(bbox.Yb) ||
+		bbox.Xl > bbox.Xr || bbox.Yt > bbox.Yb {
+		return &BBoxError{bbox}
+	}
+	return nil
+}
+
+func validateSites(sites []Vertex) error {
+	if len(sites) == 0 {
+		return ErrNoSites
+	}
+	for i, site := range sites {
+		if !isFinite(site.X) || !isFinite(site.Y) {
+			return &SiteError{i, site}
+		}
+	}
+	return nil
+}
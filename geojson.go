@@ -0,0 +1,168 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+// GeoJSON encoding of diagrams and decoding of sites
+
+package voronoi
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Options of GeoJSON export
+type GeoJSONOptions struct {
+	// Return additional properties of the cell feature, they replace the
+	// default ones of the same name. It can be nil.
+	Properties func(cell *Cell) map[string]interface{}
+	// Write edges as LineString features after the cells
+	Edges bool
+}
+
+type geoJSONGeometry struct {
+	Type        string          `json:"type"`
+	Coordinates json.RawMessage `json:"coordinates"`
+}
+
+type geoJSONFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   *geoJSONGeometry       `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type geoJSONFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+// Write the diagram as GeoJSON FeatureCollection. Every cell is a Polygon
+// feature with properties "kind": "cell", "index" of the cell and "site"
+// coordinates. Rings go counterclockwise with Y axis pointing up, as GeoJSON
+// expects. Unbounded cells and cells which weren't closed have null
+// geometry. Edges are LineString features
+// with "kind": "edge", indices of "left" and "right" cells, null when there
+// is no cell, and "border" flag. Infinite edges are left out.
+func (d *Diagram) WriteGeoJSON(w io.Writer, opts GeoJSONOptions) error {
+	collection := geoJSONFeatureCollection{
+		Type:     "FeatureCollection",
+		Features: make([]geoJSONFeature, 0, len(d.Cells)),
+	}
+
+	for _, cell := range d.Cells {
+		properties := map[string]interface{}{
+			"kind":  "cell",
+			"index": cell.Index,
+			"site":  [2]float64{cell.Site.X, cell.Site.Y},
+		}
+		if opts.Properties != nil {
+			for key, value := range opts.Properties(cell) {
+				properties[key] = value
+			}
+		}
+		var geometry *geoJSONGeometry
+		if ring := cellRing(cell); ring != nil {
+			coordinates, err := json.Marshal([][][2]float64{ring})
+			if err != nil {
+				return err
+			}
+			geometry = &geoJSONGeometry{"Polygon", coordinates}
+		}
+		collection.Features = append(collection.Features, geoJSONFeature{"Feature", geometry, properties})
+	}
+
+	if opts.Edges {
+		for _, edge := range d.Edges {
+			if edge.Kind != SegmentEdge {
+				continue
+			}
+			coordinates, err := json.Marshal([][2]float64{{edge.Va.X, edge.Va.Y}, {edge.Vb.X, edge.Vb.Y}})
+			if err != nil {
+				return err
+			}
+			properties := map[string]interface{}{
+				"kind":   "edge",
+				"left":   nil,
+				"right":  nil,
+				"border": edge.Border,
+			}
+			if edge.LeftCell != nil {
+				properties["left"] = edge.LeftCell.Index
+			}
+			if edge.RightCell != nil {
+				properties["right"] = edge.RightCell.Index
+			}
+			collection.Features = append(collection.Features,
+				geoJSONFeature{"Feature", &geoJSONGeometry{"LineString", coordinates}, properties})
+		}
+	}
+
+	return json.NewEncoder(w).Encode(collection)
+}
+
+// Closed ring of the cell start vertices, nil for cells with infinite
+// halfedges, without any, or with gaps left when cells aren't closed. It
+// goes counterclockwise with Y axis pointing up, so against the halfedges.
+func cellRing(cell *Cell) [][2]float64 {
+	n := len(cell.Halfedges)
+	if cell.Unbounded || n == 0 {
+		return nil
+	}
+	ring := make([][2]float64, 0, n+1)
+	for k, halfedge := range cell.Halfedges {
+		// the next halfedge is linked only if it starts in the vertex
+		// this one ends in, end points within tolerance share it
+		if halfedge.Edge.Kind != SegmentEdge || halfedge.Next != cell.Halfedges[(k+1)%n] {
+			return nil
+		}
+		start := halfedge.GetStartpoint()
+		ring = append(ring, [2]float64{start.X, start.Y})
+	}
+	ring = append(ring, ring[0])
+	// halfedges go clockwise with Y axis pointing up
+	for i, j := 0, len(ring)-1; i < j; i, j = i+1, j-1 {
+		ring[i], ring[j] = ring[j], ring[i]
+	}
+	return ring
+}
+
+// Read sites from Point and MultiPoint features of GeoJSON
+// FeatureCollection, in order of the features. Other features are skipped.
+func ReadGeoJSONSites(r io.Reader) ([]Vertex, error) {
+	var collection geoJSONFeatureCollection
+	if err := json.NewDecoder(r).Decode(&collection); err != nil {
+		return nil, err
+	}
+	if collection.Type != "FeatureCollection" {
+		return nil, ErrInvalidGeoJSON
+	}
+
+	var sites []Vertex
+	for _, feature := range collection.Features {
+		if feature.Geometry == nil {
+			continue
+		}
+		var points [][]float64
+		switch feature.Geometry.Type {
+		case "Point":
+			var point []float64
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &point); err != nil {
+				return nil, err
+			}
+			points = [][]float64{point}
+		case "MultiPoint":
+			if err := json.Unmarshal(feature.Geometry.Coordinates, &points); err != nil {
+				return nil, err
+			}
+		default:
+			continue
+		}
+		// positions can have altitude, which is ignored
+		for _, point := range points {
+			if len(point) < 2 {
+				return nil, ErrInvalidGeoJSON
+			}
+			sites = append(sites, Vertex{point[0], point[1]})
+		}
+	}
+	return sites, nil
+}
@@ -0,0 +1,153 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+
+package voronoi_test
+
+import (
+	"bytes"
+	"encoding/json"
+	. "github.com/pzsz/voronoi"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+type testFeature struct {
+	Geometry *struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	} `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+func TestWriteGeoJSON(t *testing.T) {
+	r := rand.New(rand.NewSource(24))
+	sites := make([]Vertex, 40)
+	for i := range sites {
+		sites[i] = Vertex{r.Float64() * 100, r.Float64() * 100}
+	}
+	diagram := ComputeDiagram(sites, NewBBox(0, 100, 0, 100), true)
+
+	var buf bytes.Buffer
+	err := diagram.WriteGeoJSON(&buf, GeoJSONOptions{
+		Edges: true,
+		Properties: func(cell *Cell) map[string]interface{} {
+			return map[string]interface{}{"name": "cell", "kind": "area"}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var collection struct {
+		Type     string        `json:"type"`
+		Features []testFeature `json:"features"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &collection); err != nil {
+		t.Fatal(err)
+	}
+	if collection.Type != "FeatureCollection" || len(collection.Features) != len(diagram.Cells)+len(diagram.Edges) {
+		t.Fatalf("Expected collection of %d features, got %q of %d", len(diagram.Cells)+len(diagram.Edges), collection.Type, len(collection.Features))
+	}
+
+	for i, cell := range diagram.Cells {
+		feature := collection.Features[i]
+		if feature.Geometry == nil || feature.Geometry.Type != "Polygon" {
+			t.Fatalf("Cell %d is not a polygon", cell.Index)
+		}
+		if feature.Properties["index"] != float64(cell.Index) || feature.Properties["name"] != "cell" || feature.Properties["kind"] != "area" {
+			t.Errorf("Wrong properties of cell %d: %v", cell.Index, feature.Properties)
+		}
+		var rings [][][2]float64
+		if err := json.Unmarshal(feature.Geometry.Coordinates, &rings); err != nil {
+			t.Fatal(err)
+		}
+		ring := rings[0]
+		if len(ring) != len(cell.Halfedges)+1 || ring[0] != ring[len(ring)-1] {
+			t.Errorf("Ring of cell %d is not closed", cell.Index)
+		}
+		area := 0.0
+		for k := 0; k+1 < len(ring); k++ {
+			area += ring[k][0]*ring[k+1][1] - ring[k][1]*ring[k+1][0]
+		}
+		if area <= 0 {
+			t.Errorf("Ring of cell %d is not counterclockwise", cell.Index)
+		}
+	}
+	for _, feature := range collection.Features[len(diagram.Cells):] {
+		if feature.Geometry.Type != "LineString" || feature.Properties["kind"] != "edge" {
+			t.Fatalf("Expected edge, got %v", feature.Properties)
+		}
+	}
+
+	unbounded, err := ComputeDiagramWithOptions(sites[:3], Options{Unbounded: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	if err := unbounded.WriteGeoJSON(&buf, GeoJSONOptions{Edges: true}); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(buf.String(), `"geometry":null`) != 3 || strings.Contains(buf.String(), "LineString") {
+		t.Errorf("Infinite geometries written: %s", buf.String())
+	}
+}
+
+func TestWriteGeoJSONOpenCells(t *testing.T) {
+	sites := []Vertex{Vertex{2, 2}, Vertex{8, 3}, Vertex{5, 8}}
+	// cells end at the box, with gaps between their edges
+	diagram := ComputeDiagram(sites, NewBBox(0, 10, 0, 10), false)
+
+	var buf bytes.Buffer
+	if err := diagram.WriteGeoJSON(&buf, GeoJSONOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	var collection struct {
+		Features []testFeature `json:"features"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &collection); err != nil {
+		t.Fatal(err)
+	}
+	if len(collection.Features) != 3 {
+		t.Fatalf("Expected 3 features, got %d", len(collection.Features))
+	}
+	for i, feature := range collection.Features {
+		if feature.Geometry != nil {
+			t.Errorf("Cell %d which wasn't closed has geometry %s", i, feature.Geometry.Coordinates)
+		}
+	}
+}
+
+func TestReadGeoJSONSites(t *testing.T) {
+	input := `{"type": "FeatureCollection", "features": [
+		{"type": "Feature", "geometry": {"type": "Point", "coordinates": [1, 2]}, "properties": {}},
+		{"type": "Feature", "geometry": {"type": "LineString", "coordinates": [[0, 0], [1, 1]]}, "properties": {}},
+		{"type": "Feature", "geometry": null, "properties": {}},
+		{"type": "Feature", "geometry": {"type": "MultiPoint", "coordinates": [[3, 4, 100], [5, 6]]}, "properties": {}}
+	]}`
+	sites, err := ReadGeoJSONSites(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Vertex{Vertex{1, 2}, Vertex{3, 4}, Vertex{5, 6}}
+	if len(sites) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, sites)
+	}
+	for i := range sites {
+		if sites[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected, sites)
+		}
+	}
+
+	for _, input := range []string{
+		`{"type": "Feature"}`,
+		`{"type": "FeatureCollection", "features": [{"type": "Feature", "geometry": {"type": "Point", "coordinates": [1]}}]}`,
+	} {
+		if _, err := ReadGeoJSONSites(strings.NewReader(input)); err != ErrInvalidGeoJSON {
+			t.Errorf("Expected ErrInvalidGeoJSON, got %v", err)
+		}
+	}
+	if _, err := ReadGeoJSONSites(strings.NewReader("{")); err == nil {
+		t.Errorf("Expected error for malformed JSON")
+	}
+}
@@ -30,9 +30,35 @@ type VerticesByY struct{ Vertices }
 
 func (s VerticesByY) Less(i, j int) bool { return s.Vertices[i].Y < s.Vertices[j].Y }
 
+// Indices of sites sorted in order of the sweep: along the Y axis, then
+// along the X axis
+type siteQueue struct {
+	sites []Vertex
+	order []int
+}
+
+func (s siteQueue) Len() int      { return len(s.order) }
+func (s siteQueue) Swap(i, j int) { s.order[i], s.order[j] = s.order[j], s.order[i] }
+func (s siteQueue) Less(i, j int) bool {
+	a, b := s.sites[s.order[i]], s.sites[s.order[j]]
+	return a.Y < b.Y || (a.Y == b.Y && a.X < b.X)
+}
+
+// Vertex of the diagram, shared by all edges meeting in it. Ends of rays
+// and lines at infinity aren't shared, they have no edges nor halfedge.
 type EdgeVertex struct {
 	Vertex
+	// Position in Diagram.Vertices, -1 for ends at infinity
+	Index int
+	// Edges meeting in the vertex
 	Edges []*Edge
+	// Cells around the vertex
+	Cells []*Cell
+	// One of the halfedges starting in the vertex. Going to Prev.Twin
+	// from it visits all of them, unless the vertex lies on the border
+	// where halfedges have no Twin, in which case the walk starts from
+	// the border one and ends at the border too.
+	Halfedge *Halfedge
 }
 
 // Edge structure
@@ -42,11 +68,38 @@ type Edge struct {
 	// Cell on the right
 	RightCell *Cell
 	// Start Vertex
-	Va EdgeVertex
+	Va *EdgeVertex
 	// End Vertex
-	Vb EdgeVertex
+	Vb *EdgeVertex
+	// Kind of the edge, edges of unbounded diagrams can be infinite
+	Kind EdgeKind
+	// Unit vector along an infinite edge, pointing away from Va for rays
+	Direction Vertex
+	// Edge was added along the clipping region to close the cell, it has
+	// no RightCell
+	Border bool
+	// Side of the clipping region a border edge lies on, see BBox.Polygon
+	// for sides of bounding box. Side i of a convex polygon goes from its
+	// i-th vertex to the next one. It is -1 for edges which aren't Border.
+	BorderSide int
+
+	// end points of the edge until they are shared with other edges
+	va, vb EdgeVertex
 }
 
+// Tells if an edge is bounded
+type EdgeKind int
+
+const (
+	// Segment from Va to Vb
+	SegmentEdge EdgeKind = iota
+	// Ray starting in Va, going along Direction. Vb is NO_VERTEX.
+	RayEdge
+	// Line through Va, going along Direction both ways. Vb is NO_VERTEX
+	// and Va is not a vertex of the diagram, it isn't shared.
+	LineEdge
+)
+
 func (e *Edge) GetOtherCell(cell *Cell) *Cell {
 	if cell == e.LeftCell {
 		return e.RightCell
@@ -58,20 +111,42 @@ func (e *Edge) GetOtherCell(cell *Cell) *Cell {
 
 func (e *Edge) GetOtherEdgeVertex(v Vertex) EdgeVertex {
 	if v == e.Va.Vertex {
-		return e.Vb
+		return *e.Vb
 	} else if v == e.Vb.Vertex {
-		return e.Va
+		return *e.Va
+	}
+	return EdgeVertex{Vertex: NO_VERTEX, Index: -1}
+}
+
+// Halfedge of the edge in the cell, nil if the edge doesn't bound it
+func (e *Edge) halfedge(cell *Cell) *Halfedge {
+	if cell == nil {
+		return nil
+	}
+	for _, halfedge := range cell.Halfedges {
+		if halfedge.Edge == e {
+			return halfedge
+		}
 	}
-	return EdgeVertex{NO_VERTEX, nil}
+	return nil
 }
 
 func newEdge(LeftCell, RightCell *Cell) *Edge {
-	return &Edge{
-		LeftCell:  LeftCell,
-		RightCell: RightCell,
-		Va:        EdgeVertex{NO_VERTEX, nil},
-		Vb:        EdgeVertex{NO_VERTEX, nil},
+	ret := &Edge{}
+	initEdge(ret, LeftCell, RightCell)
+	return ret
+}
+
+func initEdge(ret *Edge, LeftCell, RightCell *Cell) {
+	*ret = Edge{
+		LeftCell:   LeftCell,
+		RightCell:  RightCell,
+		BorderSide: -1,
+		va:         EdgeVertex{Vertex: NO_VERTEX, Index: -1},
+		vb:         EdgeVertex{Vertex: NO_VERTEX, Index: -1},
 	}
+	ret.Va = &ret.va
+	ret.Vb = &ret.vb
 }
 
 // Halfedge (directed edge)
@@ -79,6 +154,14 @@ type Halfedge struct {
 	Cell  *Cell
 	Edge  *Edge
 	Angle float64
+	// Halfedge of the same edge in the cell on the other side, going the
+	// other way. Nil for border edges.
+	Twin *Halfedge
+	// Next and previous halfedges counterclockwise around the cell, nil
+	// where the cell wasn't closed. In unbounded cells the halfedge going
+	// to infinity is followed by the one coming from there.
+	Next *Halfedge
+	Prev *Halfedge
 }
 
 // Sort interface for halfedges
@@ -93,7 +176,13 @@ type halfedgesByAngle struct{ Halfedges }
 func (s halfedgesByAngle) Less(i, j int) bool { return s.Halfedges[i].Angle > s.Halfedges[j].Angle }
 
 func newHalfedge(edge *Edge, LeftCell, RightCell *Cell) *Halfedge {
-	ret := &Halfedge{
+	ret := &Halfedge{}
+	initHalfedge(ret, edge, LeftCell, RightCell)
+	return ret
+}
+
+func initHalfedge(ret *Halfedge, edge *Edge, LeftCell, RightCell *Cell) {
+	*ret = Halfedge{
 		Cell: LeftCell,
 		Edge: edge,
 	}
@@ -118,9 +207,10 @@ func newHalfedge(edge *Edge, LeftCell, RightCell *Cell) *Halfedge {
 			ret.Angle = math.Atan2(va.X-vb.X, vb.Y-va.Y)
 		}
 	}
-	return ret
 }
 
+// Start point of the halfedge. Halfedges of infinite edges go either away
+// from Va or towards it, see GetEndpoint for what they return.
 func (h *Halfedge) GetStartpoint() Vertex {
 	if h.Edge.LeftCell == h.Cell {
 		return h.Edge.Va.Vertex
@@ -129,9 +219,30 @@ func (h *Halfedge) GetStartpoint() Vertex {
 
 }
 
+// End point of the halfedge. It is a vertex of the diagram for SegmentEdge.
+// Halfedge of RayEdge going away from Va ends at infinity and gets NO_VERTEX,
+// the other one ends in Va. Halfedges of LineEdge give NO_VERTEX for one end
+// and Va for the other, though Va is only a point on the line midway between
+// the sites, not a vertex.
 func (h *Halfedge) GetEndpoint() Vertex {
 	if h.Edge.LeftCell == h.Cell {
 		return h.Edge.Vb.Vertex
 	}
 	return h.Edge.Va.Vertex
 }
+
+// Vertex the halfedge starts in
+func (h *Halfedge) GetStartVertex() *EdgeVertex {
+	if h.Edge.LeftCell == h.Cell {
+		return h.Edge.Va
+	}
+	return h.Edge.Vb
+}
+
+// Vertex the halfedge ends in
+func (h *Halfedge) GetEndVertex() *EdgeVertex {
+	if h.Edge.LeftCell == h.Cell {
+		return h.Edge.Vb
+	}
+	return h.Edge.Va
+}
@@ -0,0 +1,109 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+// Point location: finding cells containing points
+
+package voronoi
+
+// Index of diagram cells answering which one contains a point. Cells are
+// found by walking from a nearby cell to its neighbours, which takes about
+// constant time for evenly spread sites.
+type Locator struct {
+	diagram *Diagram
+	grid    *siteGrid
+}
+
+// Create locator of the diagram cells. The diagram must not change
+// afterwards.
+func NewLocator(d *Diagram) *Locator {
+	l := &Locator{diagram: d}
+	if len(d.Cells) > 0 {
+		sites := make([]WeightedSite, len(d.Cells))
+		for i, cell := range d.Cells {
+			sites[i] = WeightedSite{cell.Site, cell.Weight}
+		}
+		l.grid = newSiteGrid(sites)
+	}
+	return l
+}
+
+// Return cell containing the vertex, or nil if it lies outside of the
+// region the diagram is clipped to. Vertices on the region boundary are
+// inside of it. Vertices shared by many cells, like the ones on edges,
+// belong to the cell with the lowest Index.
+func (l *Locator) Locate(v Vertex) *Cell {
+	if l.grid == nil || !isFinite(v.X) || !isFinite(v.Y) {
+		return nil
+	}
+	if l.diagram.region != nil && !l.diagram.region.contains(v) {
+		return nil
+	}
+
+	// power distance to sites strictly decreases, so the walk ends in the
+	// cell containing the vertex
+	cell := l.start(v)
+	dist := powerDistance(cell, v)
+	for {
+		next := cell
+		for _, halfedge := range cell.Halfedges {
+			other := halfedge.Edge.GetOtherCell(cell)
+			if other == nil {
+				continue
+			}
+			if d := powerDistance(other, v); d < dist {
+				next, dist = other, d
+			}
+		}
+		if next == cell {
+			break
+		}
+		cell = next
+	}
+
+	// cells at the same distance all touch the vertex and are connected
+	// around it, pick the lowest one among them
+	ret := cell
+	visited := map[*Cell]bool{cell: true}
+	queue := []*Cell{cell}
+	for len(queue) > 0 {
+		cell = queue[0]
+		queue = queue[1:]
+		if cell.Index < ret.Index {
+			ret = cell
+		}
+		for _, halfedge := range cell.Halfedges {
+			other := halfedge.Edge.GetOtherCell(cell)
+			if other != nil && !visited[other] && powerDistance(other, v) == dist {
+				visited[other] = true
+				queue = append(queue, other)
+			}
+		}
+	}
+	return ret
+}
+
+// Find cell to start the walk from, closest one among cells of the
+// nearest buckets which have any edges
+func (l *Locator) start(v Vertex) *Cell {
+	i := l.grid.closest(v, func(i int) bool {
+		return len(l.diagram.Cells[i].Halfedges) > 0
+	})
+	if i >= 0 {
+		return l.diagram.Cells[i]
+	}
+
+	// no cell has edges, so the closest one takes the whole region
+	ret := l.diagram.Cells[0]
+	for _, cell := range l.diagram.Cells {
+		if powerDistance(cell, v) < powerDistance(ret, v) {
+			ret = cell
+		}
+	}
+	return ret
+}
+
+// Power distance from the site of the cell, which is squared euclidean
+// distance for cells of unweighted sites
+func powerDistance(cell *Cell, v Vertex) float64 {
+	return distance2(cell.Site, v) - cell.Weight
+}
@@ -0,0 +1,114 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+
+package voronoi_test
+
+import (
+	. "github.com/pzsz/voronoi"
+	"math/rand"
+	"testing"
+)
+
+// Find cell of the closest site by checking all of them
+func closestCell(diagram *Diagram, v Vertex) *Cell {
+	var ret *Cell
+	best := 0.0
+	for _, cell := range diagram.Cells {
+		dx := cell.Site.X - v.X
+		dy := cell.Site.Y - v.Y
+		d := dx*dx + dy*dy - cell.Weight
+		if ret == nil || d < best {
+			ret, best = cell, d
+		}
+	}
+	return ret
+}
+
+func TestLocator(t *testing.T) {
+	r := rand.New(rand.NewSource(11))
+	sites := make([]Vertex, 500)
+	for i := range sites {
+		sites[i] = Vertex{r.Float64() * 100, r.Float64() * 50}
+	}
+	diagram := ComputeDiagram(sites, NewBBox(0, 100, 0, 50), true)
+	locator := NewLocator(diagram)
+
+	for i := 0; i < 2000; i++ {
+		v := Vertex{r.Float64() * 100, r.Float64() * 50}
+		if cell := locator.Locate(v); cell != closestCell(diagram, v) {
+			t.Errorf("Wrong cell of %v", v)
+		}
+	}
+
+	// corners and sides of the bounding box are inside of it
+	for _, v := range []Vertex{Vertex{0, 0}, Vertex{100, 50}, Vertex{30, 0}, Vertex{100, 20}} {
+		if cell := locator.Locate(v); cell != closestCell(diagram, v) {
+			t.Errorf("Wrong cell of %v", v)
+		}
+	}
+	for _, v := range []Vertex{Vertex{-1, 10}, Vertex{50, 50.5}, Vertex{1e9, 1e9}} {
+		if cell := locator.Locate(v); cell != nil {
+			t.Errorf("Expected no cell outside of bounding box, got %d for %v", cell.Index, v)
+		}
+	}
+
+	// unbounded diagrams cover the whole plane
+	diagram, err := ComputeDiagramWithOptions(sites, Options{Unbounded: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	locator = NewLocator(diagram)
+	for _, v := range []Vertex{Vertex{-1, 10}, Vertex{50, 50.5}, Vertex{1e9, 1e9}} {
+		if cell := locator.Locate(v); cell != closestCell(diagram, v) {
+			t.Errorf("Wrong cell of %v", v)
+		}
+	}
+}
+
+func TestLocatorTies(t *testing.T) {
+	// square lattice, edges and vertices lie on half-integer coordinates
+	var sites []Vertex
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			sites = append(sites, Vertex{float64(x), float64(y)})
+		}
+	}
+	diagram := ComputeDiagram(sites, NewBBox(-0.5, 3.5, -0.5, 3.5), true)
+	locator := NewLocator(diagram)
+
+	cases := []struct {
+		v     Vertex
+		index int
+	}{
+		{Vertex{0.5, 0}, 0},
+		{Vertex{2, 1.5}, 6},
+		{Vertex{1.5, 1.5}, 5},
+		{Vertex{2.5, 2.5}, 10},
+		{Vertex{3.5, 3.5}, 15},
+	}
+	for _, c := range cases {
+		if cell := locator.Locate(c.v); cell == nil || cell.Index != c.index {
+			t.Errorf("Expected cell %d for %v, got %v", c.index, c.v, cell)
+		}
+	}
+}
+
+func TestLocatorPowerDiagram(t *testing.T) {
+	r := rand.New(rand.NewSource(12))
+	sites := make([]WeightedSite, 200)
+	for i := range sites {
+		sites[i] = WeightedSite{Vertex{r.Float64() * 100, r.Float64() * 100}, r.Float64() * 50}
+	}
+	diagram, err := ComputePowerDiagram(sites, Options{BBox: NewBBox(0, 100, 0, 100)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	locator := NewLocator(diagram)
+	for i := 0; i < 1000; i++ {
+		v := Vertex{r.Float64() * 100, r.Float64() * 100}
+		if cell := locator.Locate(v); cell != closestCell(diagram, v) {
+			t.Errorf("Wrong cell of %v", v)
+		}
+	}
+}
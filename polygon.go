@@ -0,0 +1,348 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+// Clipping diagram cells to arbitrary polygons
+
+package voronoi
+
+import "math"
+
+// Simple polygon with optional holes. Rings can be given in either
+// orientation, they must not intersect each other.
+type Polygon struct {
+	Outer []Vertex
+	Holes [][]Vertex
+}
+
+// Closed ring of a clipped cell
+type Ring struct {
+	// Vertices of the ring. Outer rings are sorted counterclockwise, like
+	// cell halfedges, holes clockwise.
+	Vertices []Vertex
+	// Edge of the diagram the side starting at Vertices[i] lies on, nil
+	// for sides created by clipping
+	Edges []*Edge
+	// Side starting at Vertices[i] lies on the clip polygon boundary
+	Boundary []bool
+	// Ring is a hole in the clipped cell
+	Hole bool
+}
+
+// Cell of the diagram clipped to a polygon
+type ClippedCell struct {
+	Cell *Cell
+	// Rings of the part of the cell inside of the polygon, none if the cell
+	// lies outside of it. There can be many outer rings when the polygon
+	// splits the cell.
+	Rings []Ring
+}
+
+// Part of a clip polygon ring inside of a cell
+type ringChain struct {
+	vertices []Vertex
+	// positions of the first and the last vertex along the cell boundary
+	entry, exit float64
+	used        bool
+}
+
+// Intersect every cell of the diagram with the polygon. Cells have to be
+// closed. Returns clipped cells in the order of Diagram.Cells.
+func (d *Diagram) ClipToPolygon(polygon Polygon) ([]ClippedCell, error) {
+	rings := make([][]Vertex, 0, len(polygon.Holes)+1)
+	holes := make([]bool, 0, len(polygon.Holes)+1)
+	for i, ring := range append([][]Vertex{polygon.Outer}, polygon.Holes...) {
+		if len(ring) < 3 {
+			return nil, ErrInvalidPolygon
+		}
+		for _, v := range ring {
+			if !isFinite(v.X) || !isFinite(v.Y) {
+				return nil, ErrInvalidPolygon
+			}
+		}
+		// keep inside of the polygon on the same side of every ring, like
+		// inside of a cell: outer ring counterclockwise, holes clockwise
+		hole := i > 0
+		if (ringArea(ring) > 0) != hole {
+			ring = reversedRing(ring)
+		}
+		rings = append(rings, ring)
+		holes = append(holes, hole)
+	}
+
+	ret := make([]ClippedCell, len(d.Cells))
+	for i, cell := range d.Cells {
+		ret[i].Cell = cell
+		ret[i].Rings = clipCell(cell, rings, holes, d.epsilon)
+	}
+	return ret, nil
+}
+
+// Intersect closed cell with polygon rings
+func clipCell(cell *Cell, rings [][]Vertex, holes []bool, epsilon float64) []Ring {
+	n := len(cell.Halfedges)
+	if n < 3 {
+		return nil
+	}
+	region := &ConvexPolygon{Vertices: make([]Vertex, n)}
+	for i, halfedge := range cell.Halfedges {
+		region.Vertices[i] = halfedge.GetStartpoint()
+		end := halfedge.GetEndpoint()
+		next := cell.Halfedges[(i+1)%n].GetStartpoint()
+		if !equalWithEpsilon(end.X, next.X, epsilon) || !equalWithEpsilon(end.Y, next.Y, epsilon) {
+			return nil
+		}
+	}
+	// positions along the boundary are fractions of cell sides, so
+	// the tolerance depends on the length of the side
+	slack := make([]float64, n)
+	for i, a := range region.Vertices {
+		b := region.Vertices[(i+1)%n]
+		if l := math.Hypot(b.X-a.X, b.Y-a.Y); l > 0 {
+			slack[i] = epsilon / l
+		}
+	}
+
+	var ret []Ring
+	var chains []*ringChain
+	for i, ring := range rings {
+		ringChains, inside := cutRing(region, ring, epsilon)
+		if inside {
+			// ring does not cross the cell boundary
+			side := Ring{
+				Vertices: ring,
+				Edges:    make([]*Edge, len(ring)),
+				Boundary: make([]bool, len(ring)),
+				Hole:     holes[i],
+			}
+			for j := range side.Boundary {
+				side.Boundary[j] = true
+			}
+			ret = append(ret, side)
+		}
+		chains = append(chains, ringChains...)
+	}
+
+	if len(chains) == 0 {
+		// none of the rings crosses the cell boundary, so the boundary is
+		// either wholly inside of the polygon or wholly outside
+		if insidePolygon(boundaryPoint(region), rings) {
+			outer := Ring{
+				Vertices: region.Vertices,
+				Edges:    make([]*Edge, n),
+				Boundary: make([]bool, n),
+			}
+			for j, halfedge := range cell.Halfedges {
+				outer.Edges[j] = halfedge.Edge
+			}
+			ret = append([]Ring{outer}, ret...)
+		}
+		return ret
+	}
+
+	// join chains by walking counterclockwise along the cell boundary
+	// from the exit of one chain to the nearest entry of another one
+	for _, first := range chains {
+		if first.used {
+			continue
+		}
+		ring := Ring{}
+		chain := first
+		for !chain.used {
+			chain.used = true
+			for _, v := range chain.vertices[:len(chain.vertices)-1] {
+				ring.Vertices = append(ring.Vertices, v)
+				ring.Edges = append(ring.Edges, nil)
+				ring.Boundary = append(ring.Boundary, true)
+			}
+
+			side := int(math.Floor(chain.exit)) % n
+			next := chains[0]
+			distance := math.Inf(1)
+			for _, other := range chains {
+				d := other.entry - chain.exit
+				if d < -slack[side] {
+					d += float64(n)
+				}
+				if d < distance {
+					next, distance = other, d
+				}
+			}
+
+			exit := chain.vertices[len(chain.vertices)-1]
+			if distance < slack[side] {
+				// next chain starts where this one ends
+				ring.Vertices = append(ring.Vertices, exit)
+				ring.Edges = append(ring.Edges, nil)
+				ring.Boundary = append(ring.Boundary, true)
+				next.vertices = next.vertices[1:]
+				if next.used {
+					// closing the ring, drop the duplicated entry vertex
+					// of its first chain
+					ring.Vertices = ring.Vertices[1:]
+					ring.Edges = ring.Edges[1:]
+					ring.Boundary = ring.Boundary[1:]
+				}
+			} else {
+				ring.Vertices = append(ring.Vertices, exit)
+				ring.Edges = append(ring.Edges, cell.Halfedges[side].Edge)
+				ring.Boundary = append(ring.Boundary, false)
+
+				// corners of the cell passed on the way
+				end := chain.exit + distance
+				for corner := math.Floor(chain.exit) + 1; corner < end-slack[int(end)%n]; corner++ {
+					side = int(corner) % n
+					ring.Vertices = append(ring.Vertices, region.Vertices[side])
+					ring.Edges = append(ring.Edges, cell.Halfedges[side].Edge)
+					ring.Boundary = append(ring.Boundary, false)
+				}
+			}
+			chain = next
+		}
+		compactRing(&ring, epsilon)
+		if len(ring.Vertices) >= 3 {
+			ring.Hole = ringArea(ring.Vertices) > 0
+			ret = append(ret, ring)
+		}
+	}
+	return ret
+}
+
+// Cut polygon ring into chains lying inside of the region. Returns true
+// instead if the ring lies wholly inside of it.
+func cutRing(region *ConvexPolygon, ring []Vertex, epsilon float64) ([]*ringChain, bool) {
+	n := len(ring)
+	// parameters along the segment, with the tolerance scaled by its length
+	segment := func(i int) (Vertex, Vertex, float64, float64, float64, bool) {
+		a := ring[i%n]
+		b := ring[(i+1)%n]
+		slack := 0.0
+		if l := math.Hypot(b.X-a.X, b.Y-a.Y); l > 0 {
+			slack = epsilon / l
+		}
+		t0, t1, ok := region.clipLine(a, Vertex{b.X - a.X, b.Y - a.Y}, 0, 1)
+		return a, b, t0, t1, slack, ok && t1-t0 > slack
+	}
+
+	// start from a vertex outside of the region
+	start := -1
+	for i := 0; i < n && start < 0; i++ {
+		if _, _, t0, _, slack, ok := segment(i); !ok || t0 > slack {
+			start = i
+		}
+	}
+	if start < 0 {
+		return nil, true
+	}
+
+	var chains []*ringChain
+	var chain *ringChain
+	for i := start; i < start+n; i++ {
+		a, b, t0, t1, slack, ok := segment(i)
+		if !ok {
+			if chain != nil {
+				// numerical noise, leave where the last segment ended
+				chain = nil
+			}
+			continue
+		}
+		dx := b.X - a.X
+		dy := b.Y - a.Y
+		if chain == nil {
+			chain = &ringChain{vertices: []Vertex{Vertex{a.X + t0*dx, a.Y + t0*dy}}}
+			chains = append(chains, chain)
+		}
+		if t1 < 1-slack {
+			chain.vertices = append(chain.vertices, Vertex{a.X + t1*dx, a.Y + t1*dy})
+			chain = nil
+		} else {
+			chain.vertices = append(chain.vertices, b)
+		}
+	}
+
+	// drop chains which only touch the region, find where the rest
+	// crosses its boundary
+	ret := chains[:0]
+	for _, chain := range chains {
+		if len(chain.vertices) < 2 {
+			continue
+		}
+		side, t, _ := region.sideOf(chain.vertices[0], epsilon)
+		chain.entry = float64(side) + t
+		side, t, _ = region.sideOf(chain.vertices[len(chain.vertices)-1], epsilon)
+		chain.exit = float64(side) + t
+		ret = append(ret, chain)
+	}
+	return ret, false
+}
+
+// Remove repeated vertices, which appear when chains cross cell boundary
+// in its corners
+func compactRing(ring *Ring, epsilon float64) {
+	j := 0
+	for i, v := range ring.Vertices {
+		if j > 0 && equalWithEpsilon(v.X, ring.Vertices[j-1].X, epsilon) && equalWithEpsilon(v.Y, ring.Vertices[j-1].Y, epsilon) {
+			// side of the previous vertex has zero length, keep this one
+			ring.Edges[j-1] = ring.Edges[i]
+			ring.Boundary[j-1] = ring.Boundary[i]
+			continue
+		}
+		ring.Vertices[j] = v
+		ring.Edges[j] = ring.Edges[i]
+		ring.Boundary[j] = ring.Boundary[i]
+		j++
+	}
+	if j > 1 && equalWithEpsilon(ring.Vertices[0].X, ring.Vertices[j-1].X, epsilon) && equalWithEpsilon(ring.Vertices[0].Y, ring.Vertices[j-1].Y, epsilon) {
+		j--
+	}
+	ring.Vertices = ring.Vertices[:j]
+	ring.Edges = ring.Edges[:j]
+	ring.Boundary = ring.Boundary[:j]
+}
+
+// Point on the boundary of a convex cell, middle of its longest side
+func boundaryPoint(region *ConvexPolygon) Vertex {
+	n := len(region.Vertices)
+	ret := region.Vertices[0]
+	longest := -1.0
+	for i, a := range region.Vertices {
+		b := region.Vertices[(i+1)%n]
+		if l := math.Hypot(b.X-a.X, b.Y-a.Y); l > longest {
+			ret, longest = Vertex{(a.X + b.X) / 2, (a.Y + b.Y) / 2}, l
+		}
+	}
+	return ret
+}
+
+// Check if vertex lies inside of the polygon given as its rings
+func insidePolygon(v Vertex, rings [][]Vertex) bool {
+	inside := false
+	for _, ring := range rings {
+		n := len(ring)
+		for i, a := range ring {
+			b := ring[(i+1)%n]
+			if (a.Y > v.Y) != (b.Y > v.Y) && v.X < a.X+(v.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y) {
+				inside = !inside
+			}
+		}
+	}
+	return inside
+}
+
+// Twice the signed area of a ring, negative for counterclockwise ones
+func ringArea(ring []Vertex) float64 {
+	area := 0.0
+	n := len(ring)
+	for i, a := range ring {
+		b := ring[(i+1)%n]
+		area += a.X*b.Y - b.X*a.Y
+	}
+	return area
+}
+
+func reversedRing(ring []Vertex) []Vertex {
+	ret := make([]Vertex, len(ring))
+	for i, v := range ring {
+		ret[len(ring)-1-i] = v
+	}
+	return ret
+}
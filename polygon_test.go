@@ -0,0 +1,138 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+// Port of Raymond Hill's (rhill@raymondhill.net) javascript implementation
+// of Steven Forune's algorithm to compute Voronoi diagrams
+
+package voronoi_test
+
+import (
+	. "github.com/pzsz/voronoi"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func ringsArea(rings []Ring) float64 {
+	area := 0.0
+	for _, ring := range rings {
+		n := len(ring.Vertices)
+		for i, a := range ring.Vertices {
+			b := ring.Vertices[(i+1)%n]
+			area -= (a.X*b.Y - b.X*a.Y) / 2
+		}
+	}
+	return area
+}
+
+// U-shaped polygon with a hole in its bottom part
+var uPolygon = Polygon{
+	Outer: []Vertex{
+		Vertex{1, 1}, Vertex{9, 1}, Vertex{9, 9}, Vertex{6, 9},
+		Vertex{6, 3}, Vertex{4, 3}, Vertex{4, 9}, Vertex{1, 9},
+	},
+	Holes: [][]Vertex{
+		[]Vertex{Vertex{7, 3.5}, Vertex{7, 4.5}, Vertex{8, 4.5}, Vertex{8, 3.5}},
+	},
+}
+
+func TestClipToPolygon(t *testing.T) {
+	sites := []Vertex{
+		Vertex{5, 2},
+		Vertex{5, 8},
+	}
+	diagram := ComputeDiagram(sites, NewBBox(0, 10, 0, 10), true)
+
+	clipped, err := diagram.ClipToPolygon(uPolygon)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	// bottom cell keeps the hole
+	bottom := clipped[0].Rings
+	if len(bottom) != 2 || bottom[0].Hole == bottom[1].Hole {
+		t.Fatalf("Expected outer ring and a hole in the bottom cell, got %v", bottom)
+	}
+	if area := ringsArea(bottom); math.Abs(area-(8*2+2*3*2-1)) > 1e-9 {
+		t.Errorf("Wrong area of the bottom cell %f", area)
+	}
+
+	// top cell is split in two by the polygon
+	top := clipped[1].Rings
+	if len(top) != 2 || top[0].Hole || top[1].Hole {
+		t.Fatalf("Expected two outer rings in the top cell, got %v", top)
+	}
+	if area := ringsArea(top); math.Abs(area-2*3*4) > 1e-9 {
+		t.Errorf("Wrong area of the top cell %f", area)
+	}
+	for _, ring := range top {
+		for i, v := range ring.Vertices {
+			onEdge := v.Y == 5 && ring.Vertices[(i+1)%len(ring.Vertices)].Y == 5
+			if ring.Boundary[i] == onEdge || (ring.Edges[i] != nil) != onEdge {
+				t.Errorf("Wrong side %d of ring %v", i, ring.Vertices)
+			}
+		}
+	}
+
+	if _, err := diagram.ClipToPolygon(Polygon{Outer: uPolygon.Outer[:2]}); err != ErrInvalidPolygon {
+		t.Errorf("Expected ErrInvalidPolygon, got %v", err)
+	}
+}
+
+func TestClipToPolygonRandom(t *testing.T) {
+	rand.Seed(1234567)
+	sites := make([]Vertex, 100)
+	for j := range sites {
+		sites[j].X = rand.Float64() * 10
+		sites[j].Y = rand.Float64() * 10
+	}
+	diagram := ComputeDiagram(sites, NewBBox(0, 10, 0, 10), true)
+
+	clipped, err := diagram.ClipToPolygon(uPolygon)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	area := 0.0
+	for _, cell := range clipped {
+		area += ringsArea(cell.Rings)
+	}
+	if math.Abs(area-51) > 1e-6 {
+		t.Errorf("Expected area of clipped cells 51 not %f", area)
+	}
+}
+
+func TestClipToPolygonScaled(t *testing.T) {
+	for _, scale := range []float64{1e-6, 1e6} {
+		rand.Seed(1234567)
+		sites := make([]Vertex, 100)
+		for j := range sites {
+			sites[j].X = rand.Float64() * 10 * scale
+			sites[j].Y = rand.Float64() * 10 * scale
+		}
+		diagram := ComputeDiagram(sites, NewBBox(0, 10*scale, 0, 10*scale), true)
+
+		polygon := Polygon{Outer: make([]Vertex, len(uPolygon.Outer))}
+		for i, v := range uPolygon.Outer {
+			polygon.Outer[i] = Vertex{v.X * scale, v.Y * scale}
+		}
+		for _, hole := range uPolygon.Holes {
+			scaled := make([]Vertex, len(hole))
+			for i, v := range hole {
+				scaled[i] = Vertex{v.X * scale, v.Y * scale}
+			}
+			polygon.Holes = append(polygon.Holes, scaled)
+		}
+
+		clipped, err := diagram.ClipToPolygon(polygon)
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		area := 0.0
+		for _, cell := range clipped {
+			area += ringsArea(cell.Rings)
+		}
+		if expected := 51 * scale * scale; math.Abs(area-expected) > 1e-6*expected {
+			t.Errorf("Scale %g: expected area of clipped cells %g not %g", scale, expected, area)
+		}
+	}
+}
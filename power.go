@@ -0,0 +1,333 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+// Power diagrams (weighted voronoi diagrams, Laguerre diagrams)
+
+package voronoi
+
+import "math"
+
+// Site with a weight, for power diagrams
+type WeightedSite struct {
+	Vertex
+	Weight float64
+}
+
+// Compute power diagram of the sites, clipped to opts.BBox or opts.Region.
+// Cell of a site contains points p with the smallest power distance
+// |p-site|^2 - weight, cells are separated by radical axes of the sites.
+// A site can be dominated by heavier neighbours, in which case its cell
+// is empty and has no halfedges. Cells are returned in the order of sites,
+// duplicate sites with equal weights share a cell. Unbounded diagrams are
+// not supported and there is no Delaunay triangulation for power diagrams,
+// though Triangulation().Edges lists neighbouring sites.
+func ComputePowerDiagram(sites []WeightedSite, opts Options) (*Diagram, error) {
+	if opts.Unbounded {
+		return nil, ErrUnbounded
+	}
+	region := opts.BBox.Polygon()
+	if opts.Region != nil {
+		var err error
+		if region, err = validateRegion(opts.Region); err != nil {
+			return nil, err
+		}
+	} else if err := validateBBox(opts.BBox); err != nil {
+		return nil, err
+	}
+	if len(sites) == 0 {
+		return nil, ErrNoSites
+	}
+	if !isFinite(opts.Tolerance) || opts.Tolerance < 0 {
+		return nil, ErrInvalidTolerance
+	}
+	for i, site := range sites {
+		if !isFinite(site.X) || !isFinite(site.Y) || !isFinite(site.Weight) {
+			return nil, &SiteError{i, site.Vertex}
+		}
+	}
+
+	s := &Voronoi{epsilon: opts.epsilon(nil), region: region}
+	siteCells := make([]*Cell, len(sites))
+	unique := make(map[WeightedSite]*Cell)
+	var weighted []WeightedSite
+	for i, site := range sites {
+		cell := unique[site]
+		if cell == nil {
+			cell = s.newCell(site.Vertex, i)
+			cell.Weight = site.Weight
+			unique[site] = cell
+			s.cells = append(s.cells, cell)
+			weighted = append(weighted, site)
+		}
+		siteCells[i] = cell
+	}
+
+	grid := newSiteGrid(weighted)
+	edges := make(map[[2]int]*Edge)
+
+	for i, cell := range s.cells {
+		polygon := grid.powerCell(i, region, s.epsilon)
+		n := len(polygon.vertices)
+		for k, va := range polygon.vertices {
+			vb := polygon.vertices[(k+1)%n]
+			j := polygon.sides[k]
+			if j < 0 {
+				cell.OnBorder = true
+				if opts.CloseCells {
+					edge := s.createBorderEdge(cell, va, vb, -1-j)
+					cell.Halfedges = append(cell.Halfedges, s.newHalfedge(edge, cell, nil))
+				}
+				continue
+			}
+
+			// both cells of a pair find the edge between them, whichever
+			// comes first creates it
+			key := [2]int{i, j}
+			if j < i {
+				key = [2]int{j, i}
+			}
+			edge := edges[key]
+			if edge == nil {
+				edge = s.newEdge(cell, s.cells[j])
+				edge.Va.Vertex = va
+				edge.Vb.Vertex = vb
+				edges[key] = edge
+				s.edges = append(s.edges, edge)
+				s.delaunayEdges = append(s.delaunayEdges, [2]*Cell{cell, s.cells[j]})
+			}
+			cell.Halfedges = append(cell.Halfedges, s.newHalfedge(edge, cell, s.cells[j]))
+		}
+	}
+
+	return s.diagram(siteCells), nil
+}
+
+// Convex polygon of a power cell. Side starting at vertices[k] lies on
+// the radical axis with site sides[k], or on the clipping region if
+// sides[k] is negative.
+type powerPolygon struct {
+	vertices []Vertex
+	sides    []int
+}
+
+// Whole region as a polygon of a cell, with sides on its boundary
+func regionPolygon(region *ConvexPolygon) *powerPolygon {
+	polygon := &powerPolygon{
+		vertices: append([]Vertex(nil), region.Vertices...),
+		sides:    make([]int, len(region.Vertices)),
+	}
+	for k := range polygon.sides {
+		polygon.sides[k] = -1 - k
+	}
+	return polygon
+}
+
+// Keep part of the polygon where n·p <= c, new side lies on radical axis
+// with site j. Vertices closer than epsilon are merged.
+func (p *powerPolygon) clip(n Vertex, c float64, j int, epsilon float64) {
+	count := len(p.vertices)
+	ret := powerPolygon{
+		vertices: make([]Vertex, 0, count+1),
+		sides:    make([]int, 0, count+1),
+	}
+	add := func(v Vertex, side int) {
+		last := len(ret.vertices) - 1
+		if last >= 0 && equalWithEpsilon(ret.vertices[last].X, v.X, epsilon) && equalWithEpsilon(ret.vertices[last].Y, v.Y, epsilon) {
+			// side of the previous vertex would be point-like
+			ret.vertices[last] = v
+			ret.sides[last] = side
+			return
+		}
+		ret.vertices = append(ret.vertices, v)
+		ret.sides = append(ret.sides, side)
+	}
+
+	for k, a := range p.vertices {
+		b := p.vertices[(k+1)%count]
+		fa := n.X*a.X + n.Y*a.Y - c
+		fb := n.X*b.X + n.Y*b.Y - c
+		if fa <= 0 {
+			add(a, p.sides[k])
+		}
+		if (fa <= 0) != (fb <= 0) {
+			t := fa / (fa - fb)
+			x := Vertex{a.X + t*(b.X-a.X), a.Y + t*(b.Y-a.Y)}
+			if fa <= 0 {
+				// leaving along the radical axis
+				add(x, j)
+			} else {
+				add(x, p.sides[k])
+			}
+		}
+	}
+
+	last := len(ret.vertices) - 1
+	if last > 0 && equalWithEpsilon(ret.vertices[last].X, ret.vertices[0].X, epsilon) && equalWithEpsilon(ret.vertices[last].Y, ret.vertices[0].Y, epsilon) {
+		ret.vertices = ret.vertices[:last]
+		ret.sides = ret.sides[:last]
+	}
+	if len(ret.vertices) < 3 {
+		ret.vertices = nil
+		ret.sides = nil
+	}
+	*p = ret
+}
+
+// Uniform grid of weighted sites, used to visit sites in order of
+// their distance
+type siteGrid struct {
+	sites      []WeightedSite
+	buckets    [][]int
+	xl, yt     float64
+	size       float64
+	cols, rows int
+	maxWeight  float64
+}
+
+func newSiteGrid(sites []WeightedSite) *siteGrid {
+	g := &siteGrid{
+		sites:     sites,
+		xl:        math.Inf(1),
+		yt:        math.Inf(1),
+		maxWeight: math.Inf(-1),
+	}
+	xr, yb := math.Inf(-1), math.Inf(-1)
+	for _, site := range sites {
+		g.xl = math.Min(g.xl, site.X)
+		g.yt = math.Min(g.yt, site.Y)
+		xr = math.Max(xr, site.X)
+		yb = math.Max(yb, site.Y)
+		g.maxWeight = math.Max(g.maxWeight, site.Weight)
+	}
+
+	// about one site per bucket
+	g.size = math.Sqrt((xr - g.xl) * (yb - g.yt) / float64(len(sites)))
+	if g.size == 0 || math.IsNaN(g.size) {
+		g.size = math.Max(xr-g.xl, yb-g.yt)
+	}
+	if g.size == 0 {
+		g.size = 1
+	}
+	g.cols = int((xr-g.xl)/g.size) + 1
+	g.rows = int((yb-g.yt)/g.size) + 1
+	g.buckets = make([][]int, g.cols*g.rows)
+	for i, site := range sites {
+		col, row := g.bucket(site.Vertex)
+		g.buckets[row*g.cols+col] = append(g.buckets[row*g.cols+col], i)
+	}
+	return g
+}
+
+func (g *siteGrid) bucket(v Vertex) (int, int) {
+	col := int((v.X - g.xl) / g.size)
+	row := int((v.Y - g.yt) / g.size)
+	if col >= g.cols {
+		col = g.cols - 1
+	}
+	if row >= g.rows {
+		row = g.rows - 1
+	}
+	return col, row
+}
+
+// Find site with the smallest power distance to the vertex among the
+// accepted ones in the nearest ring of buckets which has any. Returns -1
+// if no site is accepted.
+func (g *siteGrid) closest(v Vertex, accept func(i int) bool) int {
+	col := clampInt(int(math.Floor((v.X-g.xl)/g.size)), 0, g.cols-1)
+	row := clampInt(int(math.Floor((v.Y-g.yt)/g.size)), 0, g.rows-1)
+
+	ret := -1
+	dist := math.Inf(1)
+	for ring := 0; ring < g.cols || ring < g.rows; ring++ {
+		for r := row - ring; r <= row+ring; r++ {
+			if r < 0 || r >= g.rows {
+				continue
+			}
+			for c := col - ring; c <= col+ring; c++ {
+				if c < 0 || c >= g.cols || (r != row-ring && r != row+ring && c != col-ring && c != col+ring) {
+					continue
+				}
+				for _, i := range g.buckets[r*g.cols+c] {
+					site := g.sites[i]
+					if d := distance2(site.Vertex, v) - site.Weight; d < dist && accept(i) {
+						ret, dist = i, d
+					}
+				}
+			}
+		}
+		if ret >= 0 {
+			return ret
+		}
+	}
+	return ret
+}
+
+func clampInt(i, low, high int) int {
+	if i < low {
+		return low
+	}
+	if i > high {
+		return high
+	}
+	return i
+}
+
+// Clip the region by radical axes of the i-th site with its neighbours.
+// Sites are visited in rings of buckets around the site, until the
+// remaining ones are too far to cut the cell.
+func (g *siteGrid) powerCell(i int, region *ConvexPolygon, epsilon float64) *powerPolygon {
+	site := g.sites[i]
+	polygon := regionPolygon(region)
+
+	col, row := g.bucket(site.Vertex)
+	for ring := 0; ; ring++ {
+		if len(polygon.vertices) == 0 {
+			return polygon
+		}
+
+		// sites in this ring and further are at least dist away, they can't
+		// cut the cell if the power distance to them is always bigger
+		radius := 0.0
+		for _, v := range polygon.vertices {
+			radius = math.Max(radius, math.Hypot(v.X-site.X, v.Y-site.Y))
+		}
+		dist := float64(ring-1) * g.size
+		if dist >= radius && (dist-radius)*(dist-radius)-g.maxWeight >= radius*radius-site.Weight {
+			return polygon
+		}
+		if col-ring < 0 && row-ring < 0 && col+ring >= g.cols && row+ring >= g.rows {
+			return polygon
+		}
+
+		for r := row - ring; r <= row+ring; r++ {
+			if r < 0 || r >= g.rows {
+				continue
+			}
+			for c := col - ring; c <= col+ring; c++ {
+				if c < 0 || c >= g.cols || (r != row-ring && r != row+ring && c != col-ring && c != col+ring) {
+					continue
+				}
+				for _, j := range g.buckets[r*g.cols+c] {
+					if j == i || len(polygon.vertices) == 0 {
+						continue
+					}
+					other := g.sites[j]
+					// power distances are equal where
+					// 2p·(other-site) = |other|^2 - |site|^2 - other.Weight + site.Weight
+					n := Vertex{other.X - site.X, other.Y - site.Y}
+					limit := (other.X*other.X + other.Y*other.Y - site.X*site.X - site.Y*site.Y - other.Weight + site.Weight) / 2
+					if n.X == 0 && n.Y == 0 {
+						// same position, heavier site takes it all
+						if other.Weight > site.Weight || (other.Weight == site.Weight && j < i) {
+							polygon.vertices = nil
+							polygon.sides = nil
+						}
+						continue
+					}
+					polygon.clip(n, limit, j, epsilon)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+// Port of Raymond Hill's (rhill@raymondhill.net) javascript implementation
+// of Steven Forune's algorithm to compute Voronoi diagrams
+
+package voronoi_test
+
+import (
+	. "github.com/pzsz/voronoi"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func cellArea(cell *Cell) float64 {
+	area := 0.0
+	for _, halfedge := range cell.Halfedges {
+		s := halfedge.GetStartpoint()
+		e := halfedge.GetEndpoint()
+		area -= (s.X*e.Y - e.X*s.Y) / 2
+	}
+	return area
+}
+
+func TestPowerDiagram(t *testing.T) {
+	opts := Options{BBox: NewBBox(0, 10, 0, 10), CloseCells: true}
+	sites := []WeightedSite{
+		WeightedSite{Vertex{3, 5}, 0},
+		WeightedSite{Vertex{7, 5}, 8},
+	}
+
+	diagram, err := ComputePowerDiagram(sites, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	verifyDiagram(diagram, 7, 2, 4, t)
+	// radical axis is at x = 4
+	if area := cellArea(diagram.Cells[0]); math.Abs(area-40) > 1e-9 {
+		t.Errorf("Expected area 40 not %f", area)
+	}
+	if diagram.Cells[1].Weight != 8 {
+		t.Errorf("Expected weight 8 not %f", diagram.Cells[1].Weight)
+	}
+
+	// light site between heavy ones has empty cell
+	sites = []WeightedSite{
+		WeightedSite{Vertex{3, 5}, 10},
+		WeightedSite{Vertex{5, 5}, 0},
+		WeightedSite{Vertex{7, 5}, 10},
+	}
+	diagram, err = ComputePowerDiagram(sites, opts)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if len(diagram.CellForSite(1).Halfedges) != 0 {
+		t.Errorf("Expected empty cell, got %d halfedges", len(diagram.CellForSite(1).Halfedges))
+	}
+	if area := closedCellsArea(diagram, t); math.Abs(area-100) > 1e-9 {
+		t.Errorf("Expected cells area 100 not %f", area)
+	}
+
+	if _, err := ComputePowerDiagram(sites, Options{Unbounded: true}); err != ErrUnbounded {
+		t.Errorf("Expected ErrUnbounded, got %v", err)
+	}
+}
+
+func TestPowerDiagramEqualWeights(t *testing.T) {
+	rand.Seed(1234567)
+	bbox := NewBBox(0, 100, 0, 100)
+	sites := make([]Vertex, 200)
+	weighted := make([]WeightedSite, len(sites))
+	for j := range sites {
+		sites[j] = Vertex{rand.Float64() * 100, rand.Float64() * 100}
+		weighted[j] = WeightedSite{sites[j], 5}
+	}
+
+	expected := ComputeDiagram(sites, bbox, true)
+	diagram, err := ComputePowerDiagram(weighted, Options{BBox: bbox, CloseCells: true})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	verifyDiagram(diagram, len(expected.Edges), len(expected.Cells), -1, t)
+	for i, cell := range diagram.Cells {
+		if math.Abs(cellArea(cell)-cellArea(expected.Cells[i])) > 1e-6 {
+			t.Errorf("Cell %d has area %f instead of %f", i, cellArea(cell), cellArea(expected.Cells[i]))
+		}
+	}
+	if area := closedCellsArea(diagram, t); math.Abs(area-100*100) > 1e-6 {
+		t.Errorf("Expected cells area 10000 not %f", area)
+	}
+}
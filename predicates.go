@@ -0,0 +1,162 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+// Adaptive precision geometric predicates, after Jonathan Richard
+// Shewchuk's "Adaptive Precision Floating-Point Arithmetic and Fast
+// Robust Geometric Predicates"
+
+package voronoi
+
+import "math"
+
+// Error bounds of the fast floating-point evaluation, epsilon being half of
+// the distance between 1 and the next float64
+const (
+	predicateEpsilon = 1.0 / (1 << 53)
+	ccwErrBound      = (3 + 16*predicateEpsilon) * predicateEpsilon
+	iccErrBound      = (10 + 96*predicateEpsilon) * predicateEpsilon
+)
+
+// Return positive value if a, b and c lie counterclockwise (with Y axis
+// pointing up), negative for clockwise and zero if they are collinear. The
+// sign is always exact, the value approximates twice the area of the
+// triangle.
+func orient2d(a, b, c Vertex) float64 {
+	detleft := (a.X - c.X) * (b.Y - c.Y)
+	detright := (a.Y - c.Y) * (b.X - c.X)
+	det := detleft - detright
+	if math.Abs(det) >= ccwErrBound*(math.Abs(detleft)+math.Abs(detright)) {
+		return det
+	}
+
+	// result is too close to zero to trust rounding, compute it exactly
+	acx := twoDiff(a.X, c.X)
+	acy := twoDiff(a.Y, c.Y)
+	bcx := twoDiff(b.X, c.X)
+	bcy := twoDiff(b.Y, c.Y)
+	return expansionDiff(expansionProduct(acx, bcy), expansionProduct(acy, bcx)).estimate()
+}
+
+// Return positive value if d lies inside of the circle through a, b and c,
+// which lie counterclockwise (with Y axis pointing up), negative if it lies
+// outside and zero if the four are cocircular. The sign is always exact.
+func incircle(a, b, c, d Vertex) float64 {
+	adx := a.X - d.X
+	bdx := b.X - d.X
+	cdx := c.X - d.X
+	ady := a.Y - d.Y
+	bdy := b.Y - d.Y
+	cdy := c.Y - d.Y
+
+	bdxcdy := bdx * cdy
+	cdxbdy := cdx * bdy
+	alift := adx*adx + ady*ady
+
+	cdxady := cdx * ady
+	adxcdy := adx * cdy
+	blift := bdx*bdx + bdy*bdy
+
+	adxbdy := adx * bdy
+	bdxady := bdx * ady
+	clift := cdx*cdx + cdy*cdy
+
+	det := alift*(bdxcdy-cdxbdy) + blift*(cdxady-adxcdy) + clift*(adxbdy-bdxady)
+	permanent := (math.Abs(bdxcdy)+math.Abs(cdxbdy))*alift +
+		(math.Abs(cdxady)+math.Abs(adxcdy))*blift +
+		(math.Abs(adxbdy)+math.Abs(bdxady))*clift
+	if math.Abs(det) > iccErrBound*permanent {
+		return det
+	}
+
+	// result is too close to zero to trust rounding, compute it exactly
+	adxe, adye := twoDiff(a.X, d.X), twoDiff(a.Y, d.Y)
+	bdxe, bdye := twoDiff(b.X, d.X), twoDiff(b.Y, d.Y)
+	cdxe, cdye := twoDiff(c.X, d.X), twoDiff(c.Y, d.Y)
+
+	lift := func(x, y expansion) expansion {
+		return expansionSum(expansionProduct(x, x), expansionProduct(y, y))
+	}
+	cross := func(x1, y1, x2, y2 expansion) expansion {
+		return expansionDiff(expansionProduct(x1, y2), expansionProduct(x2, y1))
+	}
+
+	aterm := expansionProduct(lift(adxe, adye), cross(bdxe, bdye, cdxe, cdye))
+	bterm := expansionProduct(lift(bdxe, bdye), cross(cdxe, cdye, adxe, adye))
+	cterm := expansionProduct(lift(cdxe, cdye), cross(adxe, adye, bdxe, bdye))
+	return expansionSum(expansionSum(aterm, bterm), cterm).estimate()
+}
+
+// Exact value as a sum of non-overlapping float64 components, sorted by
+// increasing magnitude, with zero components eliminated
+type expansion []float64
+
+// Approximate value of the expansion, with exact sign
+func (e expansion) estimate() float64 {
+	if len(e) == 0 {
+		return 0
+	}
+	return e[len(e)-1]
+}
+
+func twoSum(a, b float64) (x, y float64) {
+	x = a + b
+	bv := x - a
+	av := x - bv
+	y = (a - av) + (b - bv)
+	return
+}
+
+func twoProduct(a, b float64) (x, y float64) {
+	x = a * b
+	y = math.FMA(a, b, -x)
+	return
+}
+
+// Exact difference a-b as an expansion
+func twoDiff(a, b float64) expansion {
+	x, y := twoSum(a, -b)
+	return growExpansion(nil, y, x)
+}
+
+// Add components to an expansion
+func growExpansion(e expansion, components ...float64) expansion {
+	for _, b := range components {
+		ret := make(expansion, 0, len(e)+1)
+		q := b
+		for _, c := range e {
+			var h float64
+			q, h = twoSum(q, c)
+			if h != 0 {
+				ret = append(ret, h)
+			}
+		}
+		if q != 0 {
+			ret = append(ret, q)
+		}
+		e = ret
+	}
+	return e
+}
+
+func expansionSum(e, f expansion) expansion {
+	return growExpansion(e, f...)
+}
+
+func expansionDiff(e, f expansion) expansion {
+	negated := make(expansion, len(f))
+	for i, c := range f {
+		negated[i] = -c
+	}
+	return expansionSum(e, negated)
+}
+
+func expansionProduct(e, f expansion) expansion {
+	var ret expansion
+	for _, a := range e {
+		for _, b := range f {
+			x, y := twoProduct(a, b)
+			ret = growExpansion(ret, y, x)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,44 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+// Port of Raymond Hill's (rhill@raymondhill.net) javascript implementation
+// of Steven Forune's algorithm to compute Voronoi diagrams
+
+package voronoi
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOrient2dNearlyCollinear(t *testing.T) {
+	b := Vertex{12, 12}
+	c := Vertex{24, 24}
+	for i := -2; i <= 2; i++ {
+		for j := -2; j <= 2; j++ {
+			// move a by i, j units in the last place around (0.5, 0.5)
+			a := Vertex{0.5 + float64(i)*math.Pow(2, -53), 0.5 + float64(j)*math.Pow(2, -53)}
+			sign := orient2d(a, b, c)
+			// a is on the left of the line y = x when its y is larger
+			if (j > i) != (sign > 0) || (j == i) != (sign == 0) {
+				t.Errorf("Wrong orientation %g for offsets %d, %d", sign, i, j)
+			}
+		}
+	}
+}
+
+func TestIncircleCocircular(t *testing.T) {
+	// lattice points on a circle, far from the origin
+	a := Vertex{1e15 + 3, 1e15}
+	b := Vertex{1e15, 1e15 + 3}
+	c := Vertex{1e15 - 3, 1e15}
+	if d := incircle(a, b, c, Vertex{1e15, 1e15 - 3}); d != 0 {
+		t.Errorf("Expected cocircular points, got %g", d)
+	}
+	if d := incircle(a, b, c, Vertex{1e15, 1e15 - 2}); d <= 0 {
+		t.Errorf("Expected point inside, got %g", d)
+	}
+	if d := incircle(a, b, c, Vertex{1e15, 1e15 - 4}); d >= 0 {
+		t.Errorf("Expected point outside, got %g", d)
+	}
+}
@@ -0,0 +1,286 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+// Convex polygon clipping regions
+
+package voronoi
+
+import "math"
+
+// Convex polygon diagrams can be clipped to instead of bounding box
+type ConvexPolygon struct {
+	// Vertices sorted counterclockwise, like cell halfedges
+	Vertices []Vertex
+}
+
+// Create convex polygon from its vertices, given either clockwise or
+// counterclockwise. Returns ErrNotConvex if vertices do not form
+// a strictly convex polygon.
+func NewConvexPolygon(vertices []Vertex) (*ConvexPolygon, error) {
+	n := len(vertices)
+	if n < 3 {
+		return nil, ErrNotConvex
+	}
+	for _, v := range vertices {
+		if !isFinite(v.X) || !isFinite(v.Y) {
+			return nil, ErrNotConvex
+		}
+	}
+
+	sign := 0.0
+	for i := range vertices {
+		a := vertices[i]
+		b := vertices[(i+1)%n]
+		c := vertices[(i+2)%n]
+		cross := (b.X-a.X)*(c.Y-b.Y) - (b.Y-a.Y)*(c.X-b.X)
+		if cross == 0 || cross*sign < 0 {
+			return nil, ErrNotConvex
+		}
+		sign = cross
+	}
+
+	// turning angles of a simple convex polygon add up to a single turn,
+	// which rules out star-shaped ones with all turns in one direction
+	turn := 0.0
+	for i := range vertices {
+		a := vertices[i]
+		b := vertices[(i+1)%n]
+		c := vertices[(i+2)%n]
+		turn += math.Atan2((b.X-a.X)*(c.Y-b.Y)-(b.Y-a.Y)*(c.X-b.X), (b.X-a.X)*(c.X-b.X)+(b.Y-a.Y)*(c.Y-b.Y))
+	}
+	if math.Abs(turn) > 3*math.Pi {
+		return nil, ErrNotConvex
+	}
+
+	ret := &ConvexPolygon{Vertices: make([]Vertex, n)}
+	// counterclockwise with Y axis pointing down means negative turns
+	if sign > 0 {
+		for i, v := range vertices {
+			ret.Vertices[n-1-i] = v
+		}
+	} else {
+		copy(ret.Vertices, vertices)
+	}
+	return ret, nil
+}
+
+// Check region given in options, which can be created without
+// NewConvexPolygon. Returns its vertices sorted counterclockwise, or
+// ErrNotConvex if they don't form a strictly convex polygon.
+func validateRegion(p *ConvexPolygon) (*ConvexPolygon, error) {
+	return NewConvexPolygon(p.Vertices)
+}
+
+// Sides of bounding box, numbered like sides of its polygon
+const (
+	LeftSide = iota
+	BottomSide
+	RightSide
+	TopSide
+)
+
+// Return bounding box as a convex polygon, starting with the top left
+// corner and going counterclockwise
+func (bbox BBox) Polygon() *ConvexPolygon {
+	return &ConvexPolygon{Vertices: []Vertex{
+		Vertex{bbox.Xl, bbox.Yt},
+		Vertex{bbox.Xl, bbox.Yb},
+		Vertex{bbox.Xr, bbox.Yb},
+		Vertex{bbox.Xr, bbox.Yt},
+	}}
+}
+
+// Cut parameter range of line origin+t*dir to the part inside of the polygon.
+// Returns false if nothing is left.
+func (p *ConvexPolygon) clipLine(origin, dir Vertex, t0, t1 float64) (float64, float64, bool) {
+	n := len(p.Vertices)
+	for i, a := range p.Vertices {
+		b := p.Vertices[(i+1)%n]
+		sx := b.X - a.X
+		sy := b.Y - a.Y
+		// points inside are on the right of the side, where
+		// c0 + t*c1 <= 0
+		c0 := sx*(origin.Y-a.Y) - sy*(origin.X-a.X)
+		c1 := sx*dir.Y - sy*dir.X
+		if c1 == 0 {
+			if c0 > 0 {
+				return t0, t1, false
+			}
+			continue
+		}
+		r := -c0 / c1
+		if c1 > 0 {
+			if r < t1 {
+				t1 = r
+			}
+		} else if r > t0 {
+			t0 = r
+		}
+		if t0 > t1 {
+			return t0, t1, false
+		}
+	}
+	return t0, t1, true
+}
+
+// connect dangling end of the edge to the polygon boundary, see connectEdge
+func (p *ConvexPolygon) connectEdge(edge *Edge, epsilon float64) bool {
+	// skip if end point already connected
+	if edge.Vb.Vertex != NO_VERTEX {
+		return true
+	}
+
+	va := edge.Va.Vertex
+	LeftSite := edge.LeftCell.Site
+	RightSite := edge.RightCell.Site
+
+	// direction of the bisector, from the start point to the end point
+	dir := Vertex{RightSite.Y - LeftSite.Y, LeftSite.X - RightSite.X}
+	origin := va
+	t0 := math.Inf(-1)
+	if va == NO_VERTEX {
+		origin = Vertex{(LeftSite.X + RightSite.X) / 2, (LeftSite.Y + RightSite.Y) / 2}
+	} else {
+		t0 = 0
+	}
+
+	t0, t1, ok := p.clipLine(origin, dir, t0, math.Inf(1))
+	if !ok {
+		return false
+	}
+	if va == NO_VERTEX {
+		edge.Va.Vertex = Vertex{origin.X + t0*dir.X, origin.Y + t0*dir.Y}
+	}
+	edge.Vb.Vertex = Vertex{origin.X + t1*dir.X, origin.Y + t1*dir.Y}
+	return true
+}
+
+// cut edge to the polygon, see clipEdge
+func (p *ConvexPolygon) clipEdge(edge *Edge) bool {
+	a := edge.Va.Vertex
+	dir := Vertex{edge.Vb.X - a.X, edge.Vb.Y - a.Y}
+
+	t0, t1, ok := p.clipLine(a, dir, 0, 1)
+	if !ok {
+		return false
+	}
+
+	// create new vertices, like clipEdge does
+	if t0 > 0 {
+		edge.Va.Vertex = Vertex{a.X + t0*dir.X, a.Y + t0*dir.Y}
+	}
+	if t1 < 1 {
+		edge.Vb.Vertex = Vertex{a.X + t1*dir.X, a.Y + t1*dir.Y}
+	}
+	return true
+}
+
+func (p *ConvexPolygon) closeCells(s *Voronoi) {
+	s.closePolygonCells(p)
+}
+
+func (p *ConvexPolygon) onBoundary(v Vertex, epsilon float64) bool {
+	_, _, dist := p.sideOf(v, epsilon)
+	return dist < epsilon
+}
+
+func (p *ConvexPolygon) contains(v Vertex) bool {
+	n := len(p.Vertices)
+	for i, a := range p.Vertices {
+		b := p.Vertices[(i+1)%n]
+		if (b.X-a.X)*(v.Y-a.Y)-(b.Y-a.Y)*(v.X-a.X) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Find the side of the polygon closest to the vertex. Returns index of
+// the side, position of the vertex along it in [0, 1] and distance from it.
+// Vertices in corners belong to the side starting there, distances are
+// compared with the epsilon tolerance.
+func (p *ConvexPolygon) sideOf(v Vertex, epsilon float64) (side int, t, dist float64) {
+	n := len(p.Vertices)
+	dist = math.Inf(1)
+	for i, a := range p.Vertices {
+		b := p.Vertices[(i+1)%n]
+		dx := b.X - a.X
+		dy := b.Y - a.Y
+		it := ((v.X-a.X)*dx + (v.Y-a.Y)*dy) / (dx*dx + dy*dy)
+		it = math.Max(0, math.Min(1, it))
+		d := math.Hypot(a.X+it*dx-v.X, a.Y+it*dy-v.Y)
+		if d < dist-epsilon || (equalWithEpsilon(d, dist, epsilon) && it < t) {
+			side, t, dist = i, it, d
+		}
+	}
+	return
+}
+
+// Close cells by walking counterclockwise along the polygon, like
+// closeCells does for bounding box
+func (s *Voronoi) closePolygonCells(p *ConvexPolygon) {
+	n := len(p.Vertices)
+	abs_fn := math.Abs
+	epsilon := s.epsilon
+
+	for _, cell := range s.cells {
+		// trim non fully-defined halfedges and sort them counterclockwise
+		if cell.prepare() == 0 {
+			if len(s.cells) == 1 {
+				s.closeRegionCell(cell, p)
+			}
+			continue
+		}
+
+		halfedges := cell.Halfedges
+		nHalfedges := len(halfedges)
+
+		iLeft := 0
+		for iLeft < nHalfedges {
+			iRight := (iLeft + 1) % nHalfedges
+			endpoint := halfedges[iLeft].GetEndpoint()
+			startpoint := halfedges[iRight].GetStartpoint()
+			if abs_fn(endpoint.X-startpoint.X) >= epsilon || abs_fn(endpoint.Y-startpoint.Y) >= epsilon {
+				side, t, dist := p.sideOf(endpoint, epsilon)
+				// dangling end point not on the boundary, cell can't be closed
+				if dist >= epsilon {
+					cell.Open = true
+					break
+				}
+
+				// walk along the side until the next halfedge starts
+				// on it, otherwise up to the next corner
+				va := endpoint
+				vb := p.Vertices[(side+1)%n]
+				nextSide, nextT, nextDist := p.sideOf(startpoint, epsilon)
+				if nextDist >= epsilon {
+					cell.Open = true
+					break
+				}
+				if nextSide == side && nextT > t {
+					vb = startpoint
+				}
+
+				// Create new border edge. Slide it into iLeft+1 position
+				edge := s.createBorderEdge(cell, va, vb, side)
+				cell.Halfedges = append(cell.Halfedges, nil)
+				halfedges = cell.Halfedges
+				nHalfedges = len(halfedges)
+
+				copy(halfedges[iLeft+2:len(halfedges)], halfedges[iLeft+1:len(halfedges)-1])
+				halfedges[iLeft+1] = s.newHalfedge(edge, cell, nil)
+			}
+			iLeft++
+		}
+	}
+}
+
+// Close the only cell of the diagram, which is the whole region, with
+// edges along its sides
+func (s *Voronoi) closeRegionCell(cell *Cell, p *ConvexPolygon) {
+	n := len(p.Vertices)
+	for i, va := range p.Vertices {
+		edge := s.createBorderEdge(cell, va, p.Vertices[(i+1)%n], i)
+		cell.Halfedges = append(cell.Halfedges, s.newHalfedge(edge, cell, nil))
+	}
+}
@@ -0,0 +1,303 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+// Port of Raymond Hill's (rhill@raymondhill.net) javascript implementation
+// of Steven Forune's algorithm to compute Voronoi diagrams
+
+package voronoi_test
+
+import (
+	. "github.com/pzsz/voronoi"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// Verify that all cells are closed and return sum of their areas
+func closedCellsArea(diagram *Diagram, t *testing.T) float64 {
+	total := 0.0
+	for _, cell := range diagram.Cells {
+		area := 0.0
+		n := len(cell.Halfedges)
+		for i, halfedge := range cell.Halfedges {
+			s := halfedge.GetStartpoint()
+			e := halfedge.GetEndpoint()
+			next := cell.Halfedges[(i+1)%n].GetStartpoint()
+			if math.Abs(e.X-next.X) > 1e-6 || math.Abs(e.Y-next.Y) > 1e-6 {
+				t.Errorf("Cell %v is not closed: %v != %v", cell.Site, e, next)
+			}
+			area += s.X*e.Y - e.X*s.Y
+		}
+		if area > 0 {
+			t.Errorf("Cell %v is not counterclockwise", cell.Site)
+		}
+		if cell.Open {
+			t.Errorf("Cell %v is marked open", cell.Site)
+		}
+		total -= area / 2
+	}
+	return total
+}
+
+func TestConvexPolygonRegion(t *testing.T) {
+	if _, err := NewConvexPolygon([]Vertex{Vertex{0, 0}, Vertex{1, 1}}); err != ErrNotConvex {
+		t.Errorf("Expected ErrNotConvex for two vertices, got %v", err)
+	}
+	concave := []Vertex{Vertex{0, 0}, Vertex{10, 0}, Vertex{5, 2}, Vertex{10, 10}, Vertex{0, 10}}
+	if _, err := NewConvexPolygon(concave); err != ErrNotConvex {
+		t.Errorf("Expected ErrNotConvex for concave polygon, got %v", err)
+	}
+
+	// regular hexagon, given clockwise
+	hexagon := make([]Vertex, 6)
+	for i := range hexagon {
+		angle := float64(i) * math.Pi / 3
+		hexagon[i] = Vertex{50 + 50*math.Cos(angle), 50 + 50*math.Sin(angle)}
+	}
+	region, err := NewConvexPolygon(hexagon)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	rand.Seed(1234567)
+	sites := make([]Vertex, 100)
+	for j := range sites {
+		sites[j].X = rand.Float64() * 100
+		sites[j].Y = rand.Float64() * 100
+	}
+
+	diagram, err := ComputeDiagramWithOptions(sites, Options{Region: region, CloseCells: true})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+
+	hexagonArea := 3 * math.Sqrt(3) / 2 * 50 * 50
+	if area := closedCellsArea(diagram, t); math.Abs(area-hexagonArea) > 1e-6 {
+		t.Errorf("Expected cells area %f not %f", hexagonArea, area)
+	}
+
+	// regions which aren't created by NewConvexPolygon are checked, and
+	// their vertices can go either way
+	diagram, err = ComputeDiagramWithOptions(sites, Options{Region: &ConvexPolygon{hexagon}, CloseCells: true})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if area := closedCellsArea(diagram, t); math.Abs(area-hexagonArea) > 1e-6 {
+		t.Errorf("Expected cells area %f not %f for clockwise region", hexagonArea, area)
+	}
+	invalid := Options{Region: &ConvexPolygon{concave}, CloseCells: true}
+	if _, err := ComputeDiagramWithOptions(sites, invalid); err != ErrNotConvex {
+		t.Errorf("Expected ErrNotConvex for concave region, got %v", err)
+	}
+	if _, err := ComputePowerDiagram([]WeightedSite{WeightedSite{sites[0], 0}}, invalid); err != ErrNotConvex {
+		t.Errorf("Expected ErrNotConvex for concave region of power diagram, got %v", err)
+	}
+	if _, err := NewDynamicDiagram(sites, invalid); err != ErrNotConvex {
+		t.Errorf("Expected ErrNotConvex for concave region of dynamic diagram, got %v", err)
+	}
+
+	// border edges follow sides of the hexagon
+	for _, edge := range diagram.Edges {
+		if edge.RightCell != nil {
+			continue
+		}
+		onSide := false
+		for i, a := range region.Vertices {
+			b := region.Vertices[(i+1)%len(region.Vertices)]
+			cross := func(v Vertex) float64 {
+				return ((b.X-a.X)*(v.Y-a.Y) - (b.Y-a.Y)*(v.X-a.X)) / 50
+			}
+			onSide = onSide || (math.Abs(cross(edge.Va.Vertex)) < 1e-6 && math.Abs(cross(edge.Vb.Vertex)) < 1e-6)
+		}
+		if !onSide {
+			t.Errorf("Border edge %v-%v does not lie on the hexagon", edge.Va.Vertex, edge.Vb.Vertex)
+		}
+	}
+
+	// bounding box as polygon gives the same cells
+	bbox := NewBBox(0, 100, 0, 100)
+	expected := ComputeDiagram(sites, bbox, true)
+	diagram, err = ComputeDiagramWithOptions(sites, Options{Region: bbox.Polygon(), CloseCells: true})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if area := closedCellsArea(diagram, t); math.Abs(area-100*100) > 1e-6 {
+		t.Errorf("Expected cells area %f not %f", 100.0*100, area)
+	}
+	verifyDiagram(diagram, len(expected.Edges), len(expected.Cells), -1, t)
+}
+
+func TestSingleSiteRegion(t *testing.T) {
+	sites := []Vertex{Vertex{3, 4}}
+	bbox := NewBBox(0, 10, 0, 10)
+	region, err := NewConvexPolygon([]Vertex{Vertex{5, 0}, Vertex{10, 3}, Vertex{9, 10}, Vertex{1, 9}, Vertex{0, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the only cell is the whole region, like in power diagrams
+	for _, opts := range []Options{Options{BBox: bbox, CloseCells: true}, Options{Region: region, CloseCells: true}} {
+		diagram, err := ComputeDiagramWithOptions(sites, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		power, err := ComputePowerDiagram([]WeightedSite{WeightedSite{sites[0], 0}}, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := len(diagram.Cells[0].Halfedges); n != len(power.Cells[0].Halfedges) {
+			t.Errorf("Expected %d halfedges, got %d", len(power.Cells[0].Halfedges), n)
+		}
+		if area, expected := closedCellsArea(diagram, t), closedCellsArea(power, t); math.Abs(area-expected) > 1e-9 {
+			t.Errorf("Expected area %g, got %g", expected, area)
+		}
+		verifyLinks("single", diagram, true, t)
+	}
+
+	if diagram := ComputeDiagram(sites, bbox, false); len(diagram.Cells[0].Halfedges) != 0 {
+		t.Errorf("Cell which isn't closed got %d halfedges", len(diagram.Cells[0].Halfedges))
+	}
+}
+
+// Verify that border edges lie on their sides of the region, and that
+// cells touching the region are flagged
+func verifyBorder(name string, diagram *Diagram, region *ConvexPolygon, t *testing.T) {
+	n := len(region.Vertices)
+	onSide := func(v Vertex, i int) bool {
+		a := region.Vertices[i]
+		b := region.Vertices[(i+1)%n]
+		return math.Abs((b.X-a.X)*(v.Y-a.Y)-(b.Y-a.Y)*(v.X-a.X))/math.Hypot(b.X-a.X, b.Y-a.Y) < 1e-6
+	}
+	onBorder := func(v Vertex) bool {
+		for i := range region.Vertices {
+			if onSide(v, i) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, edge := range diagram.Edges {
+		if edge.Border != (edge.RightCell == nil) {
+			t.Errorf("%s: edge between %v and %v is flagged as border: %v", name, edge.LeftCell, edge.RightCell, edge.Border)
+		}
+		if edge.Border && (!onSide(edge.Va.Vertex, edge.BorderSide) || !onSide(edge.Vb.Vertex, edge.BorderSide)) {
+			t.Errorf("%s: border edge %v-%v doesn't lie on side %d", name, edge.Va.Vertex, edge.Vb.Vertex, edge.BorderSide)
+		}
+		if !edge.Border && edge.BorderSide != -1 {
+			t.Errorf("%s: edge %v-%v has side %d", name, edge.Va.Vertex, edge.Vb.Vertex, edge.BorderSide)
+		}
+	}
+	for _, cell := range diagram.Cells {
+		// only cell covers the whole region
+		touches := len(diagram.Cells) == 1
+		for _, halfedge := range cell.Halfedges {
+			touches = touches || onBorder(halfedge.GetStartpoint()) || onBorder(halfedge.GetEndpoint())
+		}
+		if cell.OnBorder != touches {
+			t.Errorf("%s: cell %d touching border is flagged %v", name, cell.Index, cell.OnBorder)
+		}
+	}
+}
+
+func TestBorderFlags(t *testing.T) {
+	r := rand.New(rand.NewSource(18))
+	sites := make([]Vertex, 200)
+	for i := range sites {
+		sites[i] = Vertex{r.Float64() * 100, r.Float64() * 100}
+	}
+	bbox := NewBBox(0, 100, 0, 100)
+
+	diagram := ComputeDiagram(sites, bbox, true)
+	verifyBorder("closed", diagram, bbox.Polygon(), t)
+	sides := make(map[int]bool)
+	for _, edge := range diagram.Edges {
+		if edge.Border {
+			sides[edge.BorderSide] = true
+		}
+		if edge.Border && edge.BorderSide == LeftSide && (math.Abs(edge.Va.X) > 1e-9 || math.Abs(edge.Vb.X) > 1e-9) {
+			t.Errorf("Edge %v-%v is not on the left side", edge.Va.Vertex, edge.Vb.Vertex)
+		}
+	}
+	if len(sides) != 4 {
+		t.Errorf("Expected border edges on 4 sides, got %d", len(sides))
+	}
+
+	// cells touch the border regardless of closing them
+	open := ComputeDiagram(sites, bbox, false)
+	verifyBorder("open", open, bbox.Polygon(), t)
+	for i, cell := range open.Cells {
+		if cell.OnBorder != diagram.Cells[i].OnBorder {
+			t.Errorf("Cell %d of open diagram is flagged %v", i, cell.OnBorder)
+		}
+	}
+
+	region, err := NewConvexPolygon([]Vertex{Vertex{50, 0}, Vertex{100, 30}, Vertex{90, 100}, Vertex{10, 90}, Vertex{0, 20}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	diagram, err = ComputeDiagramWithOptions(sites, Options{Region: region, CloseCells: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyBorder("region", diagram, region, t)
+
+	weighted := make([]WeightedSite, len(sites))
+	for i, site := range sites {
+		weighted[i] = WeightedSite{site, r.Float64() * 20}
+	}
+	diagram, err = ComputePowerDiagram(weighted, Options{Region: region, CloseCells: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifyBorder("power", diagram, region, t)
+
+	dynamic, err := NewDynamicDiagram(sites[:100], Options{BBox: bbox, CloseCells: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 50; i++ {
+		if _, err := dynamic.Remove(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+	verifyBorder("dynamic", dynamic.Diagram(), bbox.Polygon(), t)
+}
+
+func TestBorderSingleSite(t *testing.T) {
+	sites := []Vertex{Vertex{3, 4}}
+	bbox := NewBBox(0, 10, 0, 10)
+	region, err := NewConvexPolygon([]Vertex{Vertex{5, 0}, Vertex{10, 3}, Vertex{9, 10}, Vertex{1, 9}, Vertex{0, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, closeCells := range []bool{true, false} {
+		diagrams := make(map[string]*Diagram)
+		diagrams["sweep"] = ComputeDiagram(sites, bbox, closeCells)
+		diagrams["region"], err = ComputeDiagramWithOptions(sites, Options{Region: region, CloseCells: closeCells})
+		if err != nil {
+			t.Fatal(err)
+		}
+		diagrams["power"], err = ComputePowerDiagram([]WeightedSite{WeightedSite{sites[0], 1}}, Options{BBox: bbox, CloseCells: closeCells})
+		if err != nil {
+			t.Fatal(err)
+		}
+		dynamic, err := NewDynamicDiagram(sites, Options{BBox: bbox, CloseCells: closeCells})
+		if err != nil {
+			t.Fatal(err)
+		}
+		diagrams["dynamic"] = dynamic.Diagram()
+
+		for name, diagram := range diagrams {
+			polygon := bbox.Polygon()
+			if name == "region" {
+				polygon = region
+			}
+			verifyBorder(name, diagram, polygon, t)
+			if !diagram.Cells[0].OnBorder {
+				t.Errorf("%s: only cell is not on border", name)
+			}
+		}
+	}
+}
@@ -0,0 +1,168 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+// Port of Raymond Hill's (rhill@raymondhill.net) javascript implementation
+// of Steven Forune's algorithm to compute Voronoi diagrams
+
+package voronoi_test
+
+import (
+	. "github.com/pzsz/voronoi"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// Verify that every cell is closed, counterclockwise, linked and contains
+// its site, and that the cells cover the bounding box
+func verifyTopology(name string, sites []Vertex, bbox BBox, t *testing.T) *Diagram {
+	diagram := ComputeDiagram(sites, bbox, true)
+	scale := math.Max(bbox.Xr-bbox.Xl, bbox.Yb-bbox.Yt)
+
+	total := 0.0
+	for _, cell := range diagram.Cells {
+		n := len(cell.Halfedges)
+		if n < 3 {
+			t.Errorf("%s: cell %v has %d halfedges", name, cell.Site, n)
+			continue
+		}
+		area := 0.0
+		for i, halfedge := range cell.Halfedges {
+			// relative to the site, to avoid cancellation on large coordinates
+			s := halfedge.GetStartpoint()
+			e := halfedge.GetEndpoint()
+			next := cell.Halfedges[(i+1)%n].GetStartpoint()
+			if math.Abs(e.X-next.X) > 1e-9*scale || math.Abs(e.Y-next.Y) > 1e-9*scale {
+				t.Errorf("%s: cell %v is not closed", name, cell.Site)
+			}
+			cross := (s.X-cell.Site.X)*(e.Y-cell.Site.Y) - (e.X-cell.Site.X)*(s.Y-cell.Site.Y)
+			if cross > 0 {
+				t.Errorf("%s: halfedge %v-%v of cell %v goes clockwise", name, s, e, cell.Site)
+			}
+			area -= cross / 2
+		}
+		total += area
+	}
+
+	expected := (bbox.Xr - bbox.Xl) * (bbox.Yb - bbox.Yt)
+	if math.Abs(total-expected) > 1e-9*expected {
+		t.Errorf("%s: cells cover %g instead of %g", name, total, expected)
+	}
+	verifyLinks(name, diagram, true, t)
+	return diagram
+}
+
+func lattice(n int, offset, step float64) []Vertex {
+	sites := make([]Vertex, 0, n*n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			sites = append(sites, Vertex{offset + float64(i)*step, offset + float64(j)*step})
+		}
+	}
+	return sites
+}
+
+func TestDegenerateLattices(t *testing.T) {
+	for _, offset := range []float64{0, 1e3, 1e6, 1e7, 1e8} {
+		for _, step := range []float64{1, 0.1, 1e-3} {
+			sites := lattice(12, offset, step)
+			bbox := NewBBox(offset-step, offset+12*step, offset-step, offset+12*step)
+			diagram := verifyTopology("lattice", sites, bbox, t)
+			// every lattice vertex joins four cells, without point-like
+			// edges between nearly coincident vertices
+			if len(diagram.Edges) != 2*12*11+4*12 {
+				t.Errorf("lattice %g/%g: expected %d edges not %d", offset, step, 2*12*11+4*12, len(diagram.Edges))
+			}
+		}
+	}
+}
+
+func TestDegenerateCocircular(t *testing.T) {
+	sites := []Vertex{Vertex{5e5, 5e5}}
+	for i := 0; i < 64; i++ {
+		angle := float64(i) * 2 * math.Pi / 64
+		sites = append(sites, Vertex{5e5 + 1e3*math.Cos(angle), 5e5 + 1e3*math.Sin(angle)})
+	}
+	verifyTopology("circle", sites, NewBBox(5e5-2e3, 5e5+2e3, 5e5-2e3, 5e5+2e3), t)
+
+	// hexagonal lattice, every vertex is shared by three cells
+	sites = nil
+	for i := 0; i < 15; i++ {
+		for j := 0; j < 15; j++ {
+			sites = append(sites, Vertex{1e6 + float64(i) + 0.5*float64(j%2), 1e6 + float64(j)*math.Sqrt(3)/2})
+		}
+	}
+	verifyTopology("hexagonal", sites, NewBBox(1e6-1, 1e6+16, 1e6-1, 1e6+14), t)
+}
+
+func TestDegenerateCollinear(t *testing.T) {
+	var sites []Vertex
+	for i := 0; i < 10; i++ {
+		// nearly collinear, off by a few units in the last place
+		sites = append(sites, Vertex{1 + float64(i)*0.1, 1 + float64(i)*0.1 + float64(i%3)*1e-16})
+	}
+	verifyTopology("collinear", sites, NewBBox(0, 3, 0, 3), t)
+
+	sites = append(sites, sites...)
+	verifyTopology("duplicates", sites, NewBBox(0, 3, 0, 3), t)
+}
+
+func TestToleranceScale(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	points := make([]Vertex, 200)
+	for i := range points {
+		points[i] = Vertex{r.Float64(), r.Float64()}
+	}
+
+	// the same diagram at every scale, both tiny and UTM-like coordinates
+	for _, scale := range []Vertex{Vertex{1e-6, 0}, Vertex{1, 0}, Vertex{1e4, 5e5}} {
+		sites := make([]Vertex, len(points))
+		for i, p := range points {
+			sites[i] = Vertex{scale.Y + p.X*scale.X, 10*scale.Y + p.Y*scale.X}
+		}
+		bbox := NewBBox(scale.Y, scale.Y+scale.X, 10*scale.Y, 10*scale.Y+scale.X)
+		diagram := verifyTopology("scaled", sites, bbox, t)
+		if len(diagram.Edges) != 601 {
+			t.Errorf("scale %g: expected 601 edges not %d", scale.X, len(diagram.Edges))
+		}
+	}
+}
+
+func TestToleranceOption(t *testing.T) {
+	sites := []Vertex{Vertex{1, 1}, Vertex{3, 1}, Vertex{2, 2.5}}
+	bbox := NewBBox(0, 4, 0, 4)
+
+	for _, tolerance := range []float64{-1, math.NaN(), math.Inf(1)} {
+		_, err := ComputeDiagramWithOptions(sites, Options{BBox: bbox, Tolerance: tolerance})
+		if err != ErrInvalidTolerance {
+			t.Errorf("Expected ErrInvalidTolerance for %g, got %v", tolerance, err)
+		}
+	}
+
+	expected := len(ComputeDiagram(sites, bbox, true).Edges)
+	diagram, err := ComputeDiagramWithOptions(sites, Options{BBox: bbox, CloseCells: true, Tolerance: 1e-6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diagram.Edges) != expected {
+		t.Errorf("Expected %d edges, got %d", expected, len(diagram.Edges))
+	}
+}
+
+func TestToleranceNearDuplicates(t *testing.T) {
+	// sites closer than the tolerance, but not equal, can leave cells
+	// which can't be closed, they must not be walked around forever
+	r := rand.New(rand.NewSource(99))
+	for k := 0; k < 20; k++ {
+		sites := make([]Vertex, 60)
+		for i := range sites {
+			sites[i] = Vertex{float64(r.Intn(10))*10 + r.Float64()*1e-9, float64(r.Intn(10))*10 + r.Float64()*1e-9}
+		}
+		diagram := ComputeDiagram(sites, NewBBox(0, 100, 0, 100), true)
+		for _, cell := range diagram.Cells {
+			if len(cell.Halfedges) > 2*len(diagram.Edges) {
+				t.Fatalf("Cell %v has %d halfedges", cell.Site, len(cell.Halfedges))
+			}
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+// Nearest site queries over Delaunay neighbours of the diagram
+
+package voronoi
+
+import "container/heap"
+
+// Index answering nearest site queries about sites of diagram cells.
+// Searches spread from the nearest site over Delaunay neighbours: the
+// i-th nearest site is always a neighbour of one of the closer ones.
+// Weights of power diagram sites are ignored.
+type SiteIndex struct {
+	cells []*Cell
+	// indices of Delaunay neighbours of every cell
+	neighbours [][]int
+	grid       *siteGrid
+}
+
+// Create nearest site index of the diagram cells. Diagrams computed by
+// the sweep remember all Delaunay neighbours, they are computed again for
+// other ones. The diagram must not change afterwards.
+func NewSiteIndex(d *Diagram) *SiteIndex {
+	x := &SiteIndex{
+		cells:      d.Cells,
+		neighbours: make([][]int, len(d.Cells)),
+	}
+	if len(d.Cells) == 0 {
+		return x
+	}
+
+	sites := make([]WeightedSite, len(d.Cells))
+	for i, cell := range d.Cells {
+		sites[i] = WeightedSite{Vertex: cell.Site}
+	}
+	x.grid = newSiteGrid(sites)
+
+	var pairs [][2]int
+	if d.swept {
+		pairs = d.Triangulation().Edges
+	} else {
+		// other diagrams miss neighbours whose edge lies outside of the
+		// region, sweep the sites again
+		vertices := make([]Vertex, len(sites))
+		for i, site := range sites {
+			vertices[i] = site.Vertex
+		}
+		s := NewVoronoi()
+		s.epsilon = d.epsilon
+		s.sweep(vertices)
+		for _, pair := range s.delaunayEdges {
+			pairs = append(pairs, [2]int{pair[0].Index, pair[1].Index})
+		}
+	}
+	for _, pair := range pairs {
+		x.neighbours[pair[0]] = append(x.neighbours[pair[0]], pair[1])
+		x.neighbours[pair[1]] = append(x.neighbours[pair[1]], pair[0])
+	}
+	return x
+}
+
+// Return cell of the site closest to the vertex, nil if there are no cells
+func (x *SiteIndex) NearestSite(v Vertex) *Cell {
+	if x.grid == nil {
+		return nil
+	}
+	return x.cells[x.nearest(v)]
+}
+
+// Return cells of the k sites closest to the vertex, sorted by distance
+func (x *SiteIndex) KNearest(v Vertex, k int) []*Cell {
+	return x.search(v, func(d float64, n int) bool { return n < k })
+}
+
+// Return cells of the sites within radius from the vertex, sorted by
+// distance. Negative or NaN radius gives nil.
+func (x *SiteIndex) SitesInRadius(v Vertex, radius float64) []*Cell {
+	if !(radius >= 0) {
+		return nil
+	}
+	r2 := radius * radius
+	return x.search(v, func(d float64, n int) bool { return d <= r2 })
+}
+
+// Walk to neighbours closer to the vertex, which ends at the nearest site
+func (x *SiteIndex) nearest(v Vertex) int {
+	i := x.grid.closest(v, func(int) bool { return true })
+	dist := distance2(x.cells[i].Site, v)
+	for {
+		next := i
+		for _, j := range x.neighbours[i] {
+			if d := distance2(x.cells[j].Site, v); d < dist || (d == dist && j < next) {
+				next, dist = j, d
+			}
+		}
+		if next == i {
+			return i
+		}
+		i = next
+	}
+}
+
+// Visit sites in order of distance from the vertex as long as accept
+// returns true for the distance of the next one and the count of sites
+// found so far
+func (x *SiteIndex) search(v Vertex, accept func(d float64, n int) bool) []*Cell {
+	if x.grid == nil {
+		return nil
+	}
+	var ret []*Cell
+	start := x.nearest(v)
+	queue := &siteHeap{{start, distance2(x.cells[start].Site, v)}}
+	visited := map[int]bool{start: true}
+	for queue.Len() > 0 {
+		item := heap.Pop(queue).(siteDistance)
+		if !accept(item.dist, len(ret)) {
+			break
+		}
+		ret = append(ret, x.cells[item.index])
+		for _, j := range x.neighbours[item.index] {
+			if !visited[j] {
+				visited[j] = true
+				heap.Push(queue, siteDistance{j, distance2(x.cells[j].Site, v)})
+			}
+		}
+	}
+	return ret
+}
+
+type siteDistance struct {
+	index int
+	dist  float64
+}
+
+// Priority queue of sites, closest first, lower index first among sites
+// at the same distance
+type siteHeap []siteDistance
+
+func (h siteHeap) Len() int      { return len(h) }
+func (h siteHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h siteHeap) Less(i, j int) bool {
+	if h[i].dist != h[j].dist {
+		return h[i].dist < h[j].dist
+	}
+	return h[i].index < h[j].index
+}
+func (h *siteHeap) Push(x interface{}) { *h = append(*h, x.(siteDistance)) }
+func (h *siteHeap) Pop() interface{} {
+	old := *h
+	ret := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return ret
+}
@@ -0,0 +1,175 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+
+package voronoi_test
+
+import (
+	. "github.com/pzsz/voronoi"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// Sort cells by distance of their sites from the vertex by checking all
+// of them, lower index first among sites at the same distance
+func sortedBySite(diagram *Diagram, v Vertex) []*Cell {
+	ret := append([]*Cell(nil), diagram.Cells...)
+	dist := func(cell *Cell) float64 {
+		dx := cell.Site.X - v.X
+		dy := cell.Site.Y - v.Y
+		return dx*dx + dy*dy
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		if dist(ret[i]) != dist(ret[j]) {
+			return dist(ret[i]) < dist(ret[j])
+		}
+		return ret[i].Index < ret[j].Index
+	})
+	return ret
+}
+
+func within(a, b Vertex, radius float64) bool {
+	dx := a.X - b.X
+	dy := a.Y - b.Y
+	return dx*dx+dy*dy <= radius*radius
+}
+
+func checkSiteIndex(t *testing.T, diagram *Diagram, queries []Vertex) {
+	index := NewSiteIndex(diagram)
+	for _, v := range queries {
+		expected := sortedBySite(diagram, v)
+		if cell := index.NearestSite(v); cell.Site != expected[0].Site {
+			t.Errorf("Wrong nearest site of %v: %v instead of %v", v, cell.Site, expected[0].Site)
+		}
+
+		cells := index.KNearest(v, 10)
+		if len(cells) != 10 {
+			t.Fatalf("Expected 10 nearest sites of %v, got %d", v, len(cells))
+		}
+		for i, cell := range cells {
+			if cell != expected[i] {
+				t.Errorf("Wrong %d-th nearest site of %v", i, v)
+			}
+		}
+
+		cells = index.SitesInRadius(v, 10)
+		n := 0
+		for n < len(expected) && within(expected[n].Site, v, 10) {
+			n++
+		}
+		if len(cells) != n {
+			t.Fatalf("Expected %d sites within radius from %v, got %d", n, v, len(cells))
+		}
+		for i, cell := range cells {
+			if cell != expected[i] {
+				t.Errorf("Wrong %d-th site within radius from %v", i, v)
+			}
+		}
+	}
+}
+
+func TestSiteIndex(t *testing.T) {
+	r := rand.New(rand.NewSource(13))
+	sites := make([]Vertex, 300)
+	for i := range sites {
+		sites[i] = Vertex{r.Float64() * 100, r.Float64() * 100}
+	}
+	var queries []Vertex
+	for i := 0; i < 500; i++ {
+		queries = append(queries, Vertex{r.Float64()*140 - 20, r.Float64()*140 - 20})
+	}
+	queries = append(queries, sites[0], Vertex{1e6, -1e6})
+
+	checkSiteIndex(t, ComputeDiagram(sites, NewBBox(0, 100, 0, 100), true), queries)
+
+	// sites outside of the bounding box have no cells, neighbours of the
+	// rest are computed again
+	weighted := make([]WeightedSite, len(sites))
+	for i, site := range sites {
+		weighted[i] = WeightedSite{Vertex: site}
+	}
+	diagram, err := ComputePowerDiagram(weighted, Options{BBox: NewBBox(20, 80, 20, 80)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSiteIndex(t, diagram, queries)
+
+	index := NewSiteIndex(diagram)
+	for _, radius := range []float64{-1, math.NaN()} {
+		if cells := index.SitesInRadius(sites[0], radius); cells != nil {
+			t.Errorf("Expected no sites within radius %v, got %d", radius, len(cells))
+		}
+	}
+	if cells := index.SitesInRadius(sites[0], 0); len(cells) != 1 || cells[0].Site != sites[0] {
+		t.Errorf("Expected only the site itself within zero radius, got %d sites", len(cells))
+	}
+
+	if cell := NewSiteIndex(ComputeDiagram(nil, NewBBox(0, 1, 0, 1), true)).NearestSite(Vertex{}); cell != nil {
+		t.Errorf("Expected no nearest site in empty diagram, got %v", cell)
+	}
+}
+
+func TestSiteIndexLattice(t *testing.T) {
+	var sites []Vertex
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 10; x++ {
+			sites = append(sites, Vertex{float64(x), float64(y)})
+		}
+	}
+	diagram := ComputeDiagram(sites, NewBBox(-1, 10, -1, 10), true)
+	index := NewSiteIndex(diagram)
+	for _, v := range []Vertex{Vertex{4.5, 4.5}, Vertex{0, 0}, Vertex{3, 7.5}, Vertex{-5, 20}} {
+		expected := sortedBySite(diagram, v)
+		for i, cell := range index.KNearest(v, 20) {
+			if cell != expected[i] {
+				t.Errorf("Wrong %d-th nearest site of %v", i, v)
+			}
+		}
+	}
+}
+
+func benchmarkIndex(b *testing.B) (*Diagram, []Vertex) {
+	diagram := ComputeDiagram(benchmarkSites(10000), NewBBox(0, 100, 0, 100), true)
+	r := rand.New(rand.NewSource(14))
+	queries := make([]Vertex, 1024)
+	for i := range queries {
+		queries[i] = Vertex{r.Float64() * 100, r.Float64() * 100}
+	}
+	return diagram, queries
+}
+
+func BenchmarkNearestSite(b *testing.B) {
+	diagram, queries := benchmarkIndex(b)
+	index := NewSiteIndex(diagram)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		index.NearestSite(queries[i%len(queries)])
+	}
+}
+
+func BenchmarkNearestSiteBruteForce(b *testing.B) {
+	diagram, queries := benchmarkIndex(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		closestCell(diagram, queries[i%len(queries)])
+	}
+}
+
+func BenchmarkKNearest(b *testing.B) {
+	diagram, queries := benchmarkIndex(b)
+	index := NewSiteIndex(diagram)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		index.KNearest(queries[i%len(queries)], 10)
+	}
+}
+
+func BenchmarkKNearestBruteForce(b *testing.B) {
+	diagram, queries := benchmarkIndex(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = sortedBySite(diagram, queries[i%len(queries)])[:10]
+	}
+}
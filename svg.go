@@ -0,0 +1,188 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+// Export of diagrams to SVG images
+
+package voronoi
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strconv"
+)
+
+// Options of SVG export, parts of the diagram are drawn only when enabled
+type SVGOptions struct {
+	// Area of the diagram drawn. When it is zero, the area around sites and
+	// edges is drawn.
+	ViewBox BBox
+	// Width of the image, height follows from the view box. When it is
+	// zero, the image has size of the view box.
+	Width float64
+	// Draw sites as dots
+	Sites bool
+	// Draw edges between cells
+	Edges bool
+	// Draw edges along the clipping region
+	BorderEdges bool
+	// Draw edges of Delaunay triangulation between neighbouring sites
+	Delaunay bool
+	// Return fill color of the cell, in any form SVG accepts, or empty
+	// string for no fill
+	Fill func(cell *Cell) string
+	// Label sites with indices of their cells
+	Labels bool
+}
+
+// Write the diagram as SVG image. Y axis points down, like in the diagram.
+// Infinite edges are cut at the view box.
+func (d *Diagram) WriteSVG(w io.Writer, opts SVGOptions) error {
+	view := opts.ViewBox
+	if view == (BBox{}) {
+		view = d.viewBox()
+	}
+	width := view.Xr - view.Xl
+	height := view.Yb - view.Yt
+	if err := validateBBox(view); err != nil || width == 0 || height == 0 {
+		return &BBoxError{view}
+	}
+	size := math.Max(width, height)
+	imageWidth := opts.Width
+	if imageWidth <= 0 {
+		imageWidth = width
+	}
+
+	b := bufio.NewWriter(w)
+	f := func(x float64) string {
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	}
+	line := func(a, c Vertex) {
+		fmt.Fprintf(b, `<line x1="%s" y1="%s" x2="%s" y2="%s"/>`+"\n", f(a.X), f(a.Y), f(c.X), f(c.Y))
+	}
+
+	fmt.Fprintf(b, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="%s %s %s %s">`+"\n",
+		f(imageWidth), f(imageWidth*height/width), f(view.Xl), f(view.Yt), f(width), f(height))
+
+	if opts.Fill != nil {
+		fmt.Fprintf(b, `<g class="cells" stroke="none">`+"\n")
+		for _, cell := range d.Cells {
+			fill := opts.Fill(cell)
+			if fill == "" || len(cell.Halfedges) == 0 {
+				continue
+			}
+			fmt.Fprintf(b, `<path fill="%s" d="`, html.EscapeString(fill))
+			command := "M"
+			for _, v := range cellOutline(cell, 2*size) {
+				fmt.Fprintf(b, "%s%s %s ", command, f(v.X), f(v.Y))
+				command = "L"
+			}
+			fmt.Fprintf(b, `Z"/>`+"\n")
+		}
+		fmt.Fprintf(b, "</g>\n")
+	}
+
+	if opts.Delaunay {
+		fmt.Fprintf(b, `<g class="delaunay" stroke="#d03030" stroke-width="%s" stroke-dasharray="%s">`+"\n", f(size/1000), f(size/200))
+		tri := d.Triangulation()
+		for _, pair := range tri.Edges {
+			line(tri.Cells[pair[0]].Site, tri.Cells[pair[1]].Site)
+		}
+		fmt.Fprintf(b, "</g>\n")
+	}
+
+	if opts.Edges || opts.BorderEdges {
+		fmt.Fprintf(b, `<g class="edges" stroke="black" stroke-width="%s">`+"\n", f(size/500))
+		for _, edge := range d.Edges {
+			if (edge.Border && !opts.BorderEdges) || (!edge.Border && !opts.Edges) {
+				continue
+			}
+			if edge.Border {
+				fmt.Fprintf(b, `<line class="border" stroke="#3060d0" x1="%s" y1="%s" x2="%s" y2="%s"/>`+"\n",
+					f(edge.Va.X), f(edge.Va.Y), f(edge.Vb.X), f(edge.Vb.Y))
+				continue
+			}
+			a, c := edgeSegment(edge, 2*size)
+			line(a, c)
+		}
+		fmt.Fprintf(b, "</g>\n")
+	}
+
+	if opts.Sites {
+		fmt.Fprintf(b, `<g class="sites" fill="black">`+"\n")
+		for _, cell := range d.Cells {
+			fmt.Fprintf(b, `<circle cx="%s" cy="%s" r="%s"/>`+"\n", f(cell.Site.X), f(cell.Site.Y), f(size/300))
+		}
+		fmt.Fprintf(b, "</g>\n")
+	}
+
+	if opts.Labels {
+		fmt.Fprintf(b, `<g class="labels" font-family="sans-serif" font-size="%s" fill="#202020">`+"\n", f(size/60))
+		for _, cell := range d.Cells {
+			fmt.Fprintf(b, `<text x="%s" y="%s">%d</text>`+"\n", f(cell.Site.X+size/200), f(cell.Site.Y-size/200), cell.Index)
+		}
+		fmt.Fprintf(b, "</g>\n")
+	}
+
+	fmt.Fprintf(b, "</svg>\n")
+	return b.Flush()
+}
+
+// Area around sites and finite edge vertices, with a margin
+func (d *Diagram) viewBox() BBox {
+	var vertices []Vertex
+	for _, cell := range d.Cells {
+		vertices = append(vertices, cell.Site)
+	}
+	for _, v := range d.Vertices {
+		vertices = append(vertices, v.Vertex)
+	}
+	if len(vertices) == 0 {
+		return NewBBox(0, 1, 0, 1)
+	}
+	view := NewBBox(vertices[0].X, vertices[0].X, vertices[0].Y, vertices[0].Y)
+	for _, v := range vertices {
+		view.Xl = math.Min(view.Xl, v.X)
+		view.Xr = math.Max(view.Xr, v.X)
+		view.Yt = math.Min(view.Yt, v.Y)
+		view.Yb = math.Max(view.Yb, v.Y)
+	}
+	margin := math.Max(view.Xr-view.Xl, view.Yb-view.Yt) / 20
+	if margin == 0 {
+		margin = 1
+	}
+	return NewBBox(view.Xl-margin, view.Xr+margin, view.Yt-margin, view.Yb+margin)
+}
+
+// Segment of the edge, infinite edges are cut at given distance from Va
+func edgeSegment(edge *Edge, length float64) (Vertex, Vertex) {
+	va := edge.Va.Vertex
+	far := func(sign float64) Vertex {
+		return Vertex{va.X + sign*length*edge.Direction.X, va.Y + sign*length*edge.Direction.Y}
+	}
+	switch edge.Kind {
+	case RayEdge:
+		return va, far(1)
+	case LineEdge:
+		return far(-1), far(1)
+	}
+	return va, edge.Vb.Vertex
+}
+
+// Outline of the cell, with infinite halfedges cut at given distance
+func cellOutline(cell *Cell, length float64) []Vertex {
+	var ret []Vertex
+	for _, halfedge := range cell.Halfedges {
+		a, b := edgeSegment(halfedge.Edge, length)
+		if halfedge.Edge.LeftCell != cell {
+			a, b = b, a
+		}
+		ret = append(ret, a)
+		if halfedge.Next == nil || halfedge.Edge.Kind != SegmentEdge {
+			ret = append(ret, b)
+		}
+	}
+	return ret
+}
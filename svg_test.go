@@ -0,0 +1,112 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+
+package voronoi_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	. "github.com/pzsz/voronoi"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// Count elements of SVG document by name, failing when it isn't valid XML
+func countElements(data []byte, t *testing.T) map[string]int {
+	counts := make(map[string]int)
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return counts
+		}
+		if err != nil {
+			t.Fatalf("Invalid SVG: %v", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			counts[start.Name.Local]++
+		}
+	}
+}
+
+func TestWriteSVG(t *testing.T) {
+	r := rand.New(rand.NewSource(23))
+	sites := make([]Vertex, 50)
+	for i := range sites {
+		sites[i] = Vertex{r.Float64() * 100, r.Float64() * 100}
+	}
+	diagram := ComputeDiagram(sites, NewBBox(0, 100, 0, 100), true)
+
+	var buf bytes.Buffer
+	err := diagram.WriteSVG(&buf, SVGOptions{
+		Width:       400,
+		Sites:       true,
+		Edges:       true,
+		BorderEdges: true,
+		Delaunay:    true,
+		Labels:      true,
+		Fill: func(cell *Cell) string {
+			if cell.Index%2 == 0 {
+				return "#a0c0e0"
+			}
+			return ""
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := countElements(buf.Bytes(), t)
+	if counts["svg"] != 1 {
+		t.Errorf("Expected one svg element, got %d", counts["svg"])
+	}
+	if counts["circle"] != len(sites) || counts["text"] != len(sites) {
+		t.Errorf("Expected %d sites and labels, got %d and %d", len(sites), counts["circle"], counts["text"])
+	}
+	if counts["path"] != len(sites)/2 {
+		t.Errorf("Expected %d filled cells, got %d", len(sites)/2, counts["path"])
+	}
+	delaunay := len(diagram.Triangulation().Edges)
+	if counts["line"] != len(diagram.Edges)+delaunay {
+		t.Errorf("Expected %d lines, got %d", len(diagram.Edges)+delaunay, counts["line"])
+	}
+
+	// only border edges, unbounded diagram with fill escaped
+	buf.Reset()
+	err = diagram.WriteSVG(&buf, SVGOptions{BorderEdges: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	border := 0
+	for _, edge := range diagram.Edges {
+		if edge.Border {
+			border++
+		}
+	}
+	if n := countElements(buf.Bytes(), t)["line"]; n != border {
+		t.Errorf("Expected %d border edges, got %d", border, n)
+	}
+
+	unbounded, err := ComputeDiagramWithOptions(sites[:5], Options{Unbounded: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	err = unbounded.WriteSVG(&buf, SVGOptions{Edges: true, Fill: func(*Cell) string { return `"red"` }})
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts = countElements(buf.Bytes(), t)
+	if counts["path"] != 5 || counts["line"] != len(unbounded.Edges) {
+		t.Errorf("Expected 5 cells and %d edges, got %d and %d", len(unbounded.Edges), counts["path"], counts["line"])
+	}
+	if strings.Contains(buf.String(), "NaN") || strings.Contains(buf.String(), "Inf") {
+		t.Errorf("Infinite edges are not cut")
+	}
+
+	if err := diagram.WriteSVG(&buf, SVGOptions{ViewBox: NewBBox(10, 0, 0, 10)}); err == nil {
+		t.Errorf("Expected error for inverted view box")
+	}
+}
@@ -0,0 +1,169 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+// Delaunay triangulation, the dual of the voronoi diagram
+
+package voronoi
+
+import "sort"
+
+// Triangle of Delaunay triangulation
+type Triangle struct {
+	// Indices of the triangle sites in Triangulation.Cells, sorted
+	// counterclockwise like cell halfedges
+	Sites [3]int
+	// Indices of adjacent triangles in Triangulation.Triangles. Neighbors[i]
+	// shares the side opposite to Sites[i], -1 if that side lies on the
+	// convex hull.
+	Neighbors [3]int
+	// Center of the circumscribed circle, which is the voronoi vertex where
+	// cells of the three sites meet
+	Circumcenter Vertex
+	// Circumcenter lies outside of the clipping region, so it is not
+	// an edge vertex of the diagram
+	Clipped bool
+	// Vertex of the diagram at the circumcenter, its Index is the position
+	// in Diagram.Vertices. It is nil if Clipped.
+	Vertex *EdgeVertex
+}
+
+// Delaunay triangulation of diagram sites
+type Triangulation struct {
+	// Cells of the diagram, site indices refer to this slice
+	Cells []*Cell
+	// Triangles of the triangulation
+	Triangles []Triangle
+	// Pairs of indices of neighbouring sites. This includes edges which
+	// are not part of any triangle, like the ones between collinear sites.
+	Edges [][2]int
+}
+
+// Triangle recorded during the sweep
+type delaunayTriangle struct {
+	cells   [3]*Cell
+	center  Vertex
+	clipped bool
+}
+
+func (s *Voronoi) addTriangle(a, b, c *Cell, center Vertex) {
+	if a == b || b == c || a == c {
+		return
+	}
+	// keep the same orientation as the cell halfedges, which is
+	// counterclockwise with Y axis pointing down
+	cross := (b.Site.X-a.Site.X)*(c.Site.Y-a.Site.Y) - (b.Site.Y-a.Site.Y)*(c.Site.X-a.Site.X)
+	if cross > 0 {
+		b, c = c, b
+	}
+	s.triangles = append(s.triangles, delaunayTriangle{
+		cells:  [3]*Cell{a, b, c},
+		center: center,
+	})
+}
+
+func (s *Voronoi) markClippedTriangles(region clipRegion) {
+	for i := range s.triangles {
+		s.triangles[i].clipped = !region.contains(s.triangles[i].center)
+	}
+}
+
+// Return Delaunay triangulation which is dual to the diagram. Triangles
+// whose circumcenter was clipped away are included too.
+func (d *Diagram) Triangulation() *Triangulation {
+	cellIndex := make(map[*Cell]int, len(d.Cells))
+	for i, cell := range d.Cells {
+		cellIndex[cell] = i
+	}
+
+	ret := &Triangulation{
+		Cells:     d.Cells,
+		Triangles: make([]Triangle, len(d.triangles)),
+	}
+
+	type side struct{ a, b int }
+	sideKey := func(a, b int) side {
+		if a > b {
+			return side{b, a}
+		}
+		return side{a, b}
+	}
+
+	// triangles touching each side, used to find neighbours
+	sides := make(map[side][]int)
+	for i, dt := range d.triangles {
+		t := &ret.Triangles[i]
+		for j, cell := range dt.cells {
+			t.Sites[j] = cellIndex[cell]
+			t.Neighbors[j] = -1
+		}
+		t.Circumcenter = dt.center
+		t.Clipped = dt.clipped
+		if !dt.clipped {
+			t.Vertex = meetingVertex(dt.cells)
+		}
+
+		for j := 0; j < 3; j++ {
+			key := sideKey(t.Sites[(j+1)%3], t.Sites[(j+2)%3])
+			sides[key] = append(sides[key], i)
+		}
+	}
+
+	for i := range ret.Triangles {
+		t := &ret.Triangles[i]
+		for j := 0; j < 3; j++ {
+			for _, other := range sides[sideKey(t.Sites[(j+1)%3], t.Sites[(j+2)%3])] {
+				if other != i {
+					t.Neighbors[j] = other
+				}
+			}
+		}
+	}
+
+	// collect edges from triangles and from the sweep, the latter are the
+	// only ones available when all sites are collinear
+	for _, pair := range d.delaunayEdges {
+		key := sideKey(cellIndex[pair[0]], cellIndex[pair[1]])
+		if _, ok := sides[key]; !ok {
+			sides[key] = nil
+		}
+	}
+	ret.Edges = make([][2]int, 0, len(sides))
+	for key := range sides {
+		ret.Edges = append(ret.Edges, [2]int{key.a, key.b})
+	}
+	sort.Sort(sitePairs(ret.Edges))
+
+	return ret
+}
+
+// Vertex of the diagram where all the cells meet, nil if there is none
+func meetingVertex(cells [3]*Cell) *EdgeVertex {
+	for _, halfedge := range cells[0].Halfedges {
+		v := halfedge.GetStartVertex()
+		if !isVertex(halfedge.Edge, v) {
+			continue
+		}
+		found := 0
+		for _, cell := range v.Cells {
+			if cell == cells[1] || cell == cells[2] {
+				found++
+			}
+		}
+		if found == 2 {
+			return v
+		}
+	}
+	return nil
+}
+
+// For sorting edges of triangulation
+type sitePairs [][2]int
+
+func (s sitePairs) Len() int      { return len(s) }
+func (s sitePairs) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s sitePairs) Less(i, j int) bool {
+	if s[i][0] != s[j][0] {
+		return s[i][0] < s[j][0]
+	}
+	return s[i][1] < s[j][1]
+}
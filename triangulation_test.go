@@ -0,0 +1,156 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+// Port of Raymond Hill's (rhill@raymondhill.net) javascript implementation
+// of Steven Forune's algorithm to compute Voronoi diagrams
+
+package voronoi_test
+
+import (
+	. "github.com/pzsz/voronoi"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestTriangulation3Points(t *testing.T) {
+	sites := []Vertex{
+		Vertex{4, 5},
+		Vertex{6, 5},
+		Vertex{5, 8},
+	}
+
+	tri := ComputeDiagram(sites, NewBBox(0, 10, 0, 10), true).Triangulation()
+	if len(tri.Triangles) != 1 {
+		t.Fatalf("Expected 1 triangle not %d", len(tri.Triangles))
+	}
+	if len(tri.Edges) != 3 {
+		t.Errorf("Expected 3 edges not %d", len(tri.Edges))
+	}
+
+	triangle := tri.Triangles[0]
+	if math.Abs(triangle.Circumcenter.X-5) > 1e-9 || math.Abs(triangle.Circumcenter.Y-19.0/3) > 1e-9 {
+		t.Errorf("Wrong circumcenter %v", triangle.Circumcenter)
+	}
+	for _, n := range triangle.Neighbors {
+		if n != -1 {
+			t.Errorf("Expected no neighbours, got %v", triangle.Neighbors)
+		}
+	}
+}
+
+func TestTriangulationCollinear(t *testing.T) {
+	sites := []Vertex{
+		Vertex{2, 5},
+		Vertex{4, 5},
+		Vertex{6, 5},
+	}
+
+	tri := ComputeDiagram(sites, NewBBox(0, 10, 0, 10), true).Triangulation()
+	if len(tri.Triangles) != 0 {
+		t.Errorf("Expected no triangles not %d", len(tri.Triangles))
+	}
+	if len(tri.Edges) != 2 {
+		t.Errorf("Expected 2 edges not %d", len(tri.Edges))
+	}
+}
+
+func TestTriangulationRandom(t *testing.T) {
+	rand.Seed(1234567)
+	sites := make([]Vertex, 200)
+	for j := range sites {
+		sites[j].X = rand.Float64() * 100
+		sites[j].Y = rand.Float64() * 100
+	}
+
+	tri := ComputeDiagram(sites, NewBBox(0, 100, 0, 100), true).Triangulation()
+
+	hull := 0
+	for i, triangle := range tri.Triangles {
+		a := tri.Cells[triangle.Sites[0]].Site
+		b := tri.Cells[triangle.Sites[1]].Site
+		c := tri.Cells[triangle.Sites[2]].Site
+		if (b.X-a.X)*(c.Y-a.Y)-(b.Y-a.Y)*(c.X-a.X) > 0 {
+			t.Errorf("Triangle %d is not counterclockwise", i)
+		}
+
+		// circumcircle has to be empty
+		r := math.Hypot(a.X-triangle.Circumcenter.X, a.Y-triangle.Circumcenter.Y)
+		for _, cell := range tri.Cells {
+			d := math.Hypot(cell.Site.X-triangle.Circumcenter.X, cell.Site.Y-triangle.Circumcenter.Y)
+			if d < r-1e-9 {
+				t.Errorf("Site %v inside circumcircle of triangle %d", cell.Site, i)
+			}
+		}
+
+		for j, n := range triangle.Neighbors {
+			if n == -1 {
+				hull++
+				continue
+			}
+			found := false
+			for _, back := range tri.Triangles[n].Neighbors {
+				found = found || back == i
+			}
+			if !found {
+				t.Errorf("Neighbour %d of triangle %d (side %d) is not symmetric", n, i, j)
+			}
+		}
+	}
+
+	// Euler's formula for a triangulation with hull sides on its boundary
+	if len(tri.Triangles) != 2*len(tri.Cells)-2-hull {
+		t.Errorf("Expected %d triangles not %d", 2*len(tri.Cells)-2-hull, len(tri.Triangles))
+	}
+	if len(tri.Edges) != 3*len(tri.Cells)-3-hull {
+		t.Errorf("Expected %d edges not %d", 3*len(tri.Cells)-3-hull, len(tri.Edges))
+	}
+}
+
+func TestTriangulationVertices(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	sites := make([]Vertex, 200)
+	for j := range sites {
+		sites[j] = Vertex{r.Float64() * 100, r.Float64() * 100}
+	}
+	// square sites share the circumcenter of both triangles
+	sites = append(sites, Vertex{120, 20}, Vertex{140, 20}, Vertex{140, 40}, Vertex{120, 40})
+
+	diagram := ComputeDiagram(sites, NewBBox(0, 150, 0, 100), true)
+	tri := diagram.Triangulation()
+	clipped := 0
+	for i, triangle := range tri.Triangles {
+		v := triangle.Vertex
+		if triangle.Clipped {
+			clipped++
+			if v != nil {
+				t.Errorf("Clipped triangle %d has vertex %v", i, v.Vertex)
+			}
+			continue
+		}
+		if v == nil || diagram.Vertices[v.Index] != v {
+			t.Fatalf("Triangle %d has no vertex of the diagram", i)
+		}
+		if math.Hypot(v.X-triangle.Circumcenter.X, v.Y-triangle.Circumcenter.Y) > 1e-9 {
+			t.Errorf("Vertex %v of triangle %d is not at its circumcenter %v", v.Vertex, i, triangle.Circumcenter)
+		}
+		for _, site := range triangle.Sites {
+			found := false
+			for _, cell := range v.Cells {
+				found = found || cell == tri.Cells[site]
+			}
+			if !found {
+				t.Errorf("Vertex of triangle %d is not in cell %d", i, site)
+			}
+		}
+	}
+	if clipped == 0 {
+		t.Errorf("Expected some clipped triangles")
+	}
+
+	// circumcenter lies below the box
+	tri = ComputeDiagram([]Vertex{Vertex{1, 5}, Vertex{9, 5}, Vertex{5, 5.5}}, NewBBox(0, 10, 0, 10), true).Triangulation()
+	if len(tri.Triangles) != 1 || !tri.Triangles[0].Clipped || tri.Triangles[0].Vertex != nil {
+		t.Errorf("Expected clipped triangle without vertex, got %v", tri.Triangles)
+	}
+}
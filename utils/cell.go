@@ -9,6 +9,7 @@ package utils
 
 import (
 	"github.com/pzsz/voronoi"
+	"math"
 )
 
 // Calculate area of a cell
@@ -62,3 +63,22 @@ func EdgeIndex(cell *voronoi.Cell, edge *voronoi.Edge) int {
 	}	
 	return -1
 }
+
+// Calculate energy of a cell, which is the integral of squared distance
+// from its site over the cell. Centroidal voronoi tessellations have the
+// lowest sum of energies of their cells.
+func CellEnergy(cell *voronoi.Cell) float64 {
+	energy := float64(0)
+	for _, halfedge := range cell.Halfedges {
+		s := halfedge.GetStartpoint()
+		e := halfedge.GetEndpoint()
+		// triangle between the site and the halfedge, its area is signed
+		// so that triangles cancel out where the site lies outside of
+		// the cell, like the ones of clipped cells do
+		ux, uy := s.X-cell.Site.X, s.Y-cell.Site.Y
+		vx, vy := e.X-cell.Site.X, e.Y-cell.Site.Y
+		area := (ux*vy - uy*vx) / 2
+		energy += area / 6 * (ux*ux + uy*uy + vx*vx + vy*vy + ux*vx + uy*vy)
+	}
+	return math.Abs(energy)
+}
@@ -0,0 +1,87 @@
+// Copyright 2013 Przemyslaw Szczepaniak.
+// MIT License: See https://github.com/gorhill/Javascript-Voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+// Integrating density over voronoi cells
+
+package utils
+
+import (
+	"github.com/pzsz/voronoi"
+	"math"
+)
+
+// Density of sites over the plane, it must not be negative
+type Density func(voronoi.Vertex) float64
+
+// Calculate centroid of a cell weighted by density. Cell is split into
+// triangles between its first vertex and halfedges, which stay inside of
+// the cell even when its site lies outside, each one into subdivisions^2
+// smaller ones, and density is sampled in midpoints of their sides.
+// Returns the site if density is zero over the whole cell.
+func WeightedCellCentroid(cell *voronoi.Cell, density Density, subdivisions int) voronoi.Vertex {
+	m := integrateCell(cell, density, subdivisions)
+	if m.mass <= 0 {
+		return cell.Site
+	}
+	return voronoi.Vertex{m.x / m.mass, m.y / m.mass}
+}
+
+// Calculate mass of a cell, which is the integral of density over it,
+// integrated like WeightedCellCentroid does
+func CellMass(cell *voronoi.Cell, density Density, subdivisions int) float64 {
+	return integrateCell(cell, density, subdivisions).mass
+}
+
+// Integrals of density over a cell: its mass, moments along both axes
+// and energy about the site
+type cellIntegrals struct {
+	mass, x, y, energy float64
+}
+
+func integrateCell(cell *voronoi.Cell, density Density, subdivisions int) (ret cellIntegrals) {
+	if subdivisions < 1 {
+		subdivisions = 1
+	}
+	site := cell.Site
+	sample := func(v voronoi.Vertex, weight float64) {
+		rho := density(v) * weight
+		dx := v.X - site.X
+		dy := v.Y - site.Y
+		ret.mass += rho
+		ret.x += rho * v.X
+		ret.y += rho * v.Y
+		ret.energy += rho * (dx*dx + dy*dy)
+	}
+	// quadrature exact for quadratic functions
+	triangle := func(a, b, c voronoi.Vertex, area float64) {
+		sample(voronoi.Vertex{(a.X + b.X) / 2, (a.Y + b.Y) / 2}, area/3)
+		sample(voronoi.Vertex{(b.X + c.X) / 2, (b.Y + c.Y) / 2}, area/3)
+		sample(voronoi.Vertex{(c.X + a.X) / 2, (c.Y + a.Y) / 2}, area/3)
+	}
+
+	if len(cell.Halfedges) == 0 {
+		return
+	}
+	origin := cell.Halfedges[0].GetStartpoint()
+	n := float64(subdivisions)
+	for _, halfedge := range cell.Halfedges {
+		s := halfedge.GetStartpoint()
+		e := halfedge.GetEndpoint()
+		ux, uy := (s.X-origin.X)/n, (s.Y-origin.Y)/n
+		vx, vy := (e.X-origin.X)/n, (e.Y-origin.Y)/n
+		area := math.Abs(ux*vy-uy*vx) / 2
+		point := func(i, j int) voronoi.Vertex {
+			return voronoi.Vertex{origin.X + float64(i)*ux + float64(j)*vx, origin.Y + float64(i)*uy + float64(j)*vy}
+		}
+		for i := 0; i < subdivisions; i++ {
+			for j := 0; i+j < subdivisions; j++ {
+				triangle(point(i, j), point(i+1, j), point(i, j+1), area)
+				if i+j < subdivisions-1 {
+					triangle(point(i+1, j), point(i+1, j+1), point(i, j+1), area)
+				}
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,91 @@
+// Copyright 2013 Przemyslaw Szczepaniak.
+// MIT License: See https://github.com/gorhill/Javascript-Voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+
+package utils_test
+
+import (
+	. "github.com/pzsz/voronoi"
+	"github.com/pzsz/voronoi/utils"
+	"math"
+	"testing"
+)
+
+func TestConstantDensity(t *testing.T) {
+	bbox := NewBBox(0, 100, 0, 100)
+	diagram := ComputeDiagram(randomSites(21, 50, bbox), bbox, true)
+	constant := func(Vertex) float64 { return 2 }
+
+	for _, cell := range diagram.Cells {
+		centroid := utils.CellCentroid(cell)
+		weighted := utils.WeightedCellCentroid(cell, constant, 3)
+		if utils.Distance(centroid, weighted) > 1e-9 {
+			t.Errorf("Cell %d: expected centroid %v, got %v", cell.Index, centroid, weighted)
+		}
+		// area is negative, halfedges go clockwise with Y axis pointing up
+		area := math.Abs(utils.CellArea(cell))
+		if mass := utils.CellMass(cell, constant, 3); math.Abs(mass-2*area) > 1e-9*area {
+			t.Errorf("Cell %d: expected mass %g, got %g", cell.Index, 2*area, mass)
+		}
+	}
+}
+
+func TestLinearDensity(t *testing.T) {
+	// cells of a 2x2 grid are unit squares
+	sites := []Vertex{Vertex{0.5, 0.5}, Vertex{1.5, 0.5}, Vertex{0.5, 1.5}, Vertex{1.5, 1.5}}
+	diagram := ComputeDiagram(sites, NewBBox(0, 2, 0, 2), true)
+	linear := func(v Vertex) float64 { return v.X }
+
+	// over [1, 2] x [0, 1] mass is 3/2 and moment along X axis is 7/3
+	cell := diagram.CellForSite(1)
+	expected := Vertex{14.0 / 9, 0.5}
+	for _, subdivisions := range []int{1, 4} {
+		if centroid := utils.WeightedCellCentroid(cell, linear, subdivisions); utils.Distance(centroid, expected) > 1e-12 {
+			t.Errorf("Expected centroid %v, got %v", expected, centroid)
+		}
+		if mass := utils.CellMass(cell, linear, subdivisions); math.Abs(mass-1.5) > 1e-12 {
+			t.Errorf("Expected mass 1.5, got %g", mass)
+		}
+	}
+}
+
+func TestZeroMass(t *testing.T) {
+	bbox := NewBBox(0, 100, 0, 100)
+	sites := randomSites(22, 40, bbox)
+	// nothing on the left half of the box
+	right := func(v Vertex) float64 {
+		if v.X < 50 {
+			return 0
+		}
+		return 1
+	}
+
+	diagram := ComputeDiagram(sites, bbox, true)
+	for _, cell := range diagram.Cells {
+		if utils.CellMass(cell, right, 4) == 0 && utils.WeightedCellCentroid(cell, right, 4) != cell.Site {
+			t.Errorf("Cell %d without mass moved its site", cell.Index)
+		}
+	}
+
+	relaxed, _, _ := utils.RelaxWithDensity(sites, bbox, func(Vertex) float64 { return 0 }, 5, 0)
+	for i := range sites {
+		if relaxed[i] != sites[i] {
+			t.Errorf("Site %d moved to %v without any mass", i, relaxed[i])
+		}
+	}
+}
+
+func TestDensitySiteOutside(t *testing.T) {
+	// cell of the site below the box is [0, 10] x [0, 1.5]
+	diagram := ComputeDiagram([]Vertex{Vertex{5, -1}, Vertex{5, 4}}, NewBBox(0, 10, 0, 10), true)
+	cell := diagram.CellForSite(0)
+	constant := func(Vertex) float64 { return 1 }
+
+	if mass := utils.CellMass(cell, constant, 2); math.Abs(mass-15) > 1e-9 {
+		t.Errorf("Expected mass 15, got %g", mass)
+	}
+	if centroid := utils.WeightedCellCentroid(cell, constant, 2); utils.Distance(centroid, Vertex{5, 0.75}) > 1e-9 {
+		t.Errorf("Expected centroid (5, 0.75), got %v", centroid)
+	}
+}
@@ -8,9 +8,9 @@ package utils
 
 import (
 	"github.com/pzsz/voronoi"
+	"math"
 )
 
-
 // Apply lloyd relaxation algorithm to the cells.
 func LloydRelaxation(cells []*voronoi.Cell) (ret []voronoi.Vertex) {
 	ret = make([]voronoi.Vertex, len(cells))
@@ -18,4 +18,79 @@ func LloydRelaxation(cells []*voronoi.Cell) (ret []voronoi.Vertex) {
 		ret[id] = CellCentroid(cell)
 	}
 	return
-}
\ No newline at end of file
+}
+
+// Statistics of a single relaxation step
+type RelaxationStep struct {
+	// Energy of the diagram before the step, see CellEnergy
+	Energy float64
+	// The longest distance a site moved by
+	MaxDisplacement float64
+}
+
+// Apply lloyd relaxation to the sites until none of them moves further
+// than tolerance, but at most maxIterations times. Sites whose cells have
+// no area stay in place. Returns relaxed sites, their diagram with closed
+// cells and statistics of every step. Sites slice is not modified.
+func Relax(sites []voronoi.Vertex, bbox voronoi.BBox, maxIterations int, tolerance float64) ([]voronoi.Vertex, *voronoi.Diagram, []RelaxationStep) {
+	return relax(sites, bbox, maxIterations, tolerance, func(cell *voronoi.Cell) (voronoi.Vertex, float64, bool) {
+		if CellArea(cell) == 0 {
+			return cell.Site, 0, false
+		}
+		return CellCentroid(cell), CellEnergy(cell), true
+	})
+}
+
+// Fan triangles of cells are split into DensitySubdivisions^2 parts by
+// RelaxWithDensity to integrate density over them
+const DensitySubdivisions = 4
+
+// Apply lloyd relaxation like Relax, moving sites to centroids of their
+// cells weighted by density. Cells shrink where density is higher. Sites
+// whose cells have no mass stay in place, energy is weighted by density.
+func RelaxWithDensity(sites []voronoi.Vertex, bbox voronoi.BBox, density Density, maxIterations int, tolerance float64) ([]voronoi.Vertex, *voronoi.Diagram, []RelaxationStep) {
+	return relax(sites, bbox, maxIterations, tolerance, func(cell *voronoi.Cell) (voronoi.Vertex, float64, bool) {
+		m := integrateCell(cell, density, DensitySubdivisions)
+		if m.mass <= 0 {
+			return cell.Site, m.energy, false
+		}
+		return voronoi.Vertex{m.x / m.mass, m.y / m.mass}, m.energy, true
+	})
+}
+
+// Relaxation loop, centroid returns where the site of the cell moves,
+// energy of the cell and false if the site stays in place
+func relax(sites []voronoi.Vertex, bbox voronoi.BBox, maxIterations int, tolerance float64,
+	centroid func(cell *voronoi.Cell) (voronoi.Vertex, float64, bool)) ([]voronoi.Vertex, *voronoi.Diagram, []RelaxationStep) {
+	sites = append([]voronoi.Vertex(nil), sites...)
+	computer := voronoi.NewVoronoi()
+	diagram := computer.Compute(sites, bbox, true)
+
+	var steps []RelaxationStep
+	for len(steps) < maxIterations {
+		step := RelaxationStep{}
+		targets := make(map[*voronoi.Cell]voronoi.Vertex, len(diagram.Cells))
+		for _, cell := range diagram.Cells {
+			target, energy, ok := centroid(cell)
+			step.Energy += energy
+			if ok {
+				targets[cell] = target
+			}
+		}
+		for i := range sites {
+			target, ok := targets[diagram.CellForSite(i)]
+			if !ok {
+				continue
+			}
+			step.MaxDisplacement = math.Max(step.MaxDisplacement, Distance(sites[i], target))
+			sites[i] = target
+		}
+		steps = append(steps, step)
+
+		diagram = computer.Compute(sites, bbox, true)
+		if step.MaxDisplacement <= tolerance {
+			break
+		}
+	}
+	return sites, diagram, steps
+}
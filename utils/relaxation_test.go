@@ -0,0 +1,99 @@
+// Copyright 2013 Przemyslaw Szczepaniak.
+// MIT License: See https://github.com/gorhill/Javascript-Voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+
+package utils_test
+
+import (
+	. "github.com/pzsz/voronoi"
+	"github.com/pzsz/voronoi/utils"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func randomSites(seed int64, count int, bbox BBox) []Vertex {
+	r := rand.New(rand.NewSource(seed))
+	sites := make([]Vertex, count)
+	for i := range sites {
+		sites[i] = Vertex{bbox.Xl + r.Float64()*(bbox.Xr-bbox.Xl), bbox.Yt + r.Float64()*(bbox.Yb-bbox.Yt)}
+	}
+	return sites
+}
+
+func TestRelaxEnergy(t *testing.T) {
+	bbox := NewBBox(0, 100, 0, 100)
+	sites := randomSites(18, 100, bbox)
+	input := append([]Vertex(nil), sites...)
+
+	relaxed, diagram, steps := utils.Relax(sites, bbox, 30, 0)
+	if len(steps) != 30 {
+		t.Fatalf("Expected 30 steps, got %d", len(steps))
+	}
+	for i := 1; i < len(steps); i++ {
+		if steps[i].Energy > steps[i-1].Energy*(1+1e-9) {
+			t.Errorf("Energy of step %d went up from %g to %g", i, steps[i-1].Energy, steps[i].Energy)
+		}
+	}
+	if len(relaxed) != len(sites) || len(diagram.Cells) != len(sites) {
+		t.Errorf("Expected %d sites and cells, got %d and %d", len(sites), len(relaxed), len(diagram.Cells))
+	}
+	for i := range sites {
+		if sites[i] != input[i] {
+			t.Fatalf("Input site %d was modified", i)
+		}
+		if diagram.CellForSite(i).Site != relaxed[i] {
+			t.Errorf("Diagram doesn't belong to relaxed site %d", i)
+		}
+	}
+}
+
+func TestRelaxTolerance(t *testing.T) {
+	bbox := NewBBox(0, 100, 0, 100)
+	sites := randomSites(19, 50, bbox)
+
+	_, _, steps := utils.Relax(sites, bbox, 100, 1e9)
+	if len(steps) != 1 {
+		t.Errorf("Expected a single step with huge tolerance, got %d", len(steps))
+	}
+
+	_, _, steps = utils.Relax(sites, bbox, 1000, 0.01)
+	if len(steps) == 1000 {
+		t.Fatalf("Relaxation didn't converge")
+	}
+	for i, step := range steps {
+		last := i == len(steps)-1
+		if last != (step.MaxDisplacement <= 0.01) {
+			t.Errorf("Step %d of %d moved sites by %g", i, len(steps), step.MaxDisplacement)
+		}
+	}
+
+	if _, _, steps := utils.Relax(sites, bbox, 0, 0); len(steps) != 0 {
+		t.Errorf("Expected no steps, got %d", len(steps))
+	}
+}
+
+func TestRelaxZeroArea(t *testing.T) {
+	bbox := NewBBox(0, 100, 0, 100)
+	// far away site has no cell within the box
+	sites := append(randomSites(20, 30, bbox), Vertex{1000, 1000})
+
+	relaxed, _, _ := utils.Relax(sites, bbox, 5, 0)
+	if relaxed[30] != sites[30] {
+		t.Errorf("Site without area moved to %v", relaxed[30])
+	}
+	for i := 0; i < 30; i++ {
+		if relaxed[i] == sites[i] {
+			t.Errorf("Site %d didn't move", i)
+		}
+	}
+}
+
+func TestCellEnergySiteOutside(t *testing.T) {
+	// cell of the site below the box is [0, 10] x [0, 1.5]
+	diagram := ComputeDiagram([]Vertex{Vertex{5, -1}, Vertex{5, 4}}, NewBBox(0, 10, 0, 10), true)
+	if energy := utils.CellEnergy(diagram.CellForSite(0)); math.Abs(energy-173.75) > 1e-9 {
+		t.Errorf("Expected energy 173.75, got %g", energy)
+	}
+}
@@ -8,17 +8,266 @@ package utils
 
 import (
 	"github.com/pzsz/voronoi"
+	"math"
 	"math/rand"
 )
 
 // Generate random sites in given bounding box
 func RandomSites(bbox voronoi.BBox, count int) []voronoi.Vertex {
+	return UniformSites(bbox, count, nil)
+}
+
+// Generators below take source of randomness, which gives the same sites
+// for the same seed when created with rand.New(rand.NewSource(seed)).
+// They use the global source if it is nil.
+func float64Source(r *rand.Rand) func() float64 {
+	if r == nil {
+		return rand.Float64
+	}
+	return r.Float64
+}
+
+func intSource(r *rand.Rand) func(n int) int {
+	if r == nil {
+		return rand.Intn
+	}
+	return r.Intn
+}
+
+// Generate sites uniformly distributed in the bounding box
+func UniformSites(bbox voronoi.BBox, count int, r *rand.Rand) []voronoi.Vertex {
+	float64n := float64Source(r)
 	sites := make([]voronoi.Vertex, count)
 	w := bbox.Xr - bbox.Xl
 	h := bbox.Yb - bbox.Yt
 	for j := 0; j < count; j++ {
-		sites[j].X = rand.Float64() * w + bbox.Xl
-		sites[j].Y = rand.Float64() * h + bbox.Yt
+		sites[j].X = float64n()*w + bbox.Xl
+		sites[j].Y = float64n()*h + bbox.Yt
+	}
+	return sites
+}
+
+// Generate site in every cell of a grid of cols x rows cells covering the
+// bounding box. Sites are moved away from centers of the cells randomly,
+// by up to jitter times half of the cell size along each axis, so jitter
+// of 0 gives regular grid and 1 lets sites anywhere in their cells.
+func JitteredGridSites(bbox voronoi.BBox, cols, rows int, jitter float64, r *rand.Rand) []voronoi.Vertex {
+	if cols <= 0 || rows <= 0 {
+		return nil
+	}
+	float64n := float64Source(r)
+	w := (bbox.Xr - bbox.Xl) / float64(cols)
+	h := (bbox.Yb - bbox.Yt) / float64(rows)
+	sites := make([]voronoi.Vertex, 0, cols*rows)
+	for y := 0; y < rows; y++ {
+		for x := 0; x < cols; x++ {
+			sites = append(sites, voronoi.Vertex{
+				bbox.Xl + (float64(x)+0.5+jitter*(float64n()-0.5))*w,
+				bbox.Yt + (float64(y)+0.5+jitter*(float64n()-0.5))*h,
+			})
+		}
+	}
+	return sites
+}
+
+// Generate sites of a hexagonal grid filling the bounding box, spacing
+// apart from their six neighbours, which makes cells regular hexagons.
+// Sites are moved randomly by up to jitter times half of spacing along
+// each axis, staying in the bounding box.
+func HexGridSites(bbox voronoi.BBox, spacing, jitter float64, r *rand.Rand) []voronoi.Vertex {
+	if !(spacing > 0) {
+		return nil
+	}
+	float64n := float64Source(r)
+	rowHeight := spacing * math.Sqrt(3) / 2
+	var sites []voronoi.Vertex
+	for row := 0; bbox.Yt+float64(row)*rowHeight <= bbox.Yb; row++ {
+		// odd rows are shifted by half of spacing
+		offset := float64(row%2) * spacing / 2
+		for col := 0; bbox.Xl+offset+float64(col)*spacing <= bbox.Xr; col++ {
+			x := bbox.Xl + offset + float64(col)*spacing + jitter*(float64n()-0.5)*spacing
+			y := bbox.Yt + float64(row)*rowHeight + jitter*(float64n()-0.5)*spacing
+			sites = append(sites, voronoi.Vertex{
+				math.Max(bbox.Xl, math.Min(bbox.Xr, x)),
+				math.Max(bbox.Yt, math.Min(bbox.Yb, y)),
+			})
+		}
+	}
+	return sites
+}
+
+// Generate sites of Halton sequence with bases 2 and 3 in the bounding
+// box, which cover it more evenly than random sites do. Skip leading
+// elements of the sequence to get different sites, negative skip is 0.
+func HaltonSites(bbox voronoi.BBox, count, skip int) []voronoi.Vertex {
+	if skip < 0 {
+		skip = 0
+	}
+	radicalInverse := func(i, base int) float64 {
+		ret := 0.0
+		f := 1.0 / float64(base)
+		for ; i > 0; i /= base {
+			ret += f * float64(i%base)
+			f /= float64(base)
+		}
+		return ret
+	}
+	sites := make([]voronoi.Vertex, count)
+	for j := range sites {
+		// the first element is the corner, start from the next one
+		i := skip + j + 1
+		sites[j] = voronoi.Vertex{
+			bbox.Xl + radicalInverse(i, 2)*(bbox.Xr-bbox.Xl),
+			bbox.Yt + radicalInverse(i, 3)*(bbox.Yb-bbox.Yt),
+		}
+	}
+	return sites
+}
+
+// Generate sites of two dimensional Sobol sequence in the bounding box,
+// like HaltonSites does
+func SobolSites(bbox voronoi.BBox, count, skip int) []voronoi.Vertex {
+	const bits = 32
+	if skip < 0 {
+		skip = 0
+	}
+	// direction numbers, the first dimension is van der Corput sequence
+	// and the second one comes from polynomial x + 1
+	var v1, v2 [bits]uint32
+	for k := 0; k < bits; k++ {
+		v1[k] = 1 << uint(bits-1-k)
+		if k == 0 {
+			v2[k] = 1 << (bits - 1)
+		} else {
+			v2[k] = v2[k-1] ^ v2[k-1]>>1
+		}
+	}
+
+	sites := make([]voronoi.Vertex, count)
+	for j := range sites {
+		// the first element is the corner, start from the next one
+		i := uint64(skip + j + 1)
+		var x, y uint32
+		// gray code of the index selects direction numbers
+		gray := i ^ i>>1
+		for k := 0; gray > 0 && k < bits; k++ {
+			if gray&1 != 0 {
+				x ^= v1[k]
+				y ^= v2[k]
+			}
+			gray >>= 1
+		}
+		sites[j] = voronoi.Vertex{
+			bbox.Xl + float64(x)/(1<<bits)*(bbox.Xr-bbox.Xl),
+			bbox.Yt + float64(y)/(1<<bits)*(bbox.Yb-bbox.Yt),
+		}
+	}
+	return sites
+}
+
+// Generate sites in the bounding box, at least radius apart from each
+// other, with Bridson's algorithm. New sites are tried around the existing
+// ones until no more fit. Uses the global source of randomness if r is nil.
+func PoissonDiskSites(bbox voronoi.BBox, radius float64, r *rand.Rand) []voronoi.Vertex {
+	return VariablePoissonDiskSites(bbox, func(voronoi.Vertex) float64 { return radius }, radius, radius, r)
+}
+
+// Generate sites like PoissonDiskSites, with radius varying over the
+// bounding box. Every site lies outside of the disks of the other ones,
+// radius of the disk around each site is given by the function, clamped
+// to [minRadius, maxRadius]. Small minRadius to maxRadius ratio makes the
+// search for neighbours slow. Returns nil unless minRadius is positive and
+// maxRadius is finite and not less than it.
+func VariablePoissonDiskSites(bbox voronoi.BBox, radius func(voronoi.Vertex) float64, minRadius, maxRadius float64, r *rand.Rand) []voronoi.Vertex {
+	// attempts to place new site around an active one
+	const attempts = 30
+
+	if !(minRadius > 0) || !(maxRadius >= minRadius) || math.IsInf(maxRadius, 1) || !(bbox.Xr >= bbox.Xl) || !(bbox.Yb >= bbox.Yt) {
+		return nil
+	}
+	float64n := float64Source(r)
+	intn := intSource(r)
+	clamp := func(v voronoi.Vertex) float64 {
+		return math.Max(minRadius, math.Min(maxRadius, radius(v)))
+	}
+
+	// grid cells are small enough to hold a single site, unless there
+	// would be more than maxCells of them, sites within maxRadius lie in
+	// the given range of cells around each other
+	const maxCells = 1 << 20
+	width, height := bbox.Xr-bbox.Xl, bbox.Yb-bbox.Yt
+	size := minRadius / math.Sqrt2
+	size = math.Max(size, math.Sqrt(width*height/maxCells))
+	size = math.Max(size, math.Max(width, height)/maxCells)
+	w := int(width/size) + 1
+	h := int(height/size) + 1
+	reach := int(math.Ceil(maxRadius / size))
+	grid := make([][]int, w*h)
+	cellOf := func(v voronoi.Vertex) (int, int) {
+		return int((v.X - bbox.Xl) / size), int((v.Y - bbox.Yt) / size)
+	}
+
+	var sites []voronoi.Vertex
+	var radii []float64
+	fits := func(v voronoi.Vertex, rv float64) bool {
+		cx, cy := cellOf(v)
+		x0, x1, y0, y1 := cx-reach, cx+reach, cy-reach, cy+reach
+		if x0 < 0 {
+			x0 = 0
+		}
+		if x1 >= w {
+			x1 = w - 1
+		}
+		if y0 < 0 {
+			y0 = 0
+		}
+		if y1 >= h {
+			y1 = h - 1
+		}
+		for y := y0; y <= y1; y++ {
+			for x := x0; x <= x1; x++ {
+				for _, i := range grid[y*w+x] {
+					if Distance(v, sites[i]) < math.Max(rv, radii[i]) {
+						return false
+					}
+				}
+			}
+		}
+		return true
+	}
+	add := func(v voronoi.Vertex, rv float64) {
+		cx, cy := cellOf(v)
+		grid[cy*w+cx] = append(grid[cy*w+cx], len(sites))
+		sites = append(sites, v)
+		radii = append(radii, rv)
+	}
+
+	first := voronoi.Vertex{bbox.Xl + float64n()*(bbox.Xr-bbox.Xl), bbox.Yt + float64n()*(bbox.Yb-bbox.Yt)}
+	add(first, clamp(first))
+	active := []int{0}
+	for len(active) > 0 {
+		k := intn(len(active))
+		site := sites[active[k]]
+		rs := radii[active[k]]
+		placed := false
+		for attempt := 0; attempt < attempts && !placed; attempt++ {
+			// uniformly over the annulus between rs and 2*rs
+			angle := float64n() * 2 * math.Pi
+			d := math.Sqrt(rs*rs + float64n()*3*rs*rs)
+			v := voronoi.Vertex{site.X + d*math.Cos(angle), site.Y + d*math.Sin(angle)}
+			if v.X < bbox.Xl || v.X > bbox.Xr || v.Y < bbox.Yt || v.Y > bbox.Yb {
+				continue
+			}
+			if rv := clamp(v); fits(v, rv) {
+				add(v, rv)
+				active = append(active, len(sites)-1)
+				placed = true
+			}
+		}
+		if !placed {
+			active[k] = active[len(active)-1]
+			active = active[:len(active)-1]
+		}
 	}
 	return sites
-}
\ No newline at end of file
+}
@@ -0,0 +1,166 @@
+// Copyright 2013 Przemyslaw Szczepaniak.
+// MIT License: See https://github.com/gorhill/Javascript-Voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+
+package utils_test
+
+import (
+	. "github.com/pzsz/voronoi"
+	"github.com/pzsz/voronoi/utils"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func inBBox(v Vertex, bbox BBox) bool {
+	return v.X >= bbox.Xl && v.X <= bbox.Xr && v.Y >= bbox.Yt && v.Y <= bbox.Yb
+}
+
+func TestVariablePoissonDisk(t *testing.T) {
+	bbox := NewBBox(0, 100, 0, 50)
+	// disks grow from left to right
+	radius := func(v Vertex) float64 { return 1 + v.X/25 }
+	clamped := func(v Vertex) float64 { return math.Max(2, math.Min(4, radius(v))) }
+
+	sites := utils.VariablePoissonDiskSites(bbox, radius, 2, 4, rand.New(rand.NewSource(21)))
+	if len(sites) < 100 {
+		t.Fatalf("Expected the box filled with sites, got %d", len(sites))
+	}
+	for i, a := range sites {
+		if !inBBox(a, bbox) {
+			t.Errorf("Site %v is out of the box", a)
+		}
+		for _, b := range sites[:i] {
+			if d := utils.Distance(a, b); d < math.Max(clamped(a), clamped(b)) {
+				t.Fatalf("Sites %v and %v are only %g apart", a, b, d)
+			}
+		}
+	}
+
+	again := utils.VariablePoissonDiskSites(bbox, radius, 2, 4, rand.New(rand.NewSource(21)))
+	if len(again) != len(sites) {
+		t.Fatalf("Expected %d sites for the same seed, got %d", len(sites), len(again))
+	}
+	for i := range sites {
+		if sites[i] != again[i] {
+			t.Fatalf("Site %d differs for the same seed: %v and %v", i, sites[i], again[i])
+		}
+	}
+	if other := utils.VariablePoissonDiskSites(bbox, radius, 2, 4, rand.New(rand.NewSource(22))); other[0] == sites[0] {
+		t.Errorf("Sites are the same for different seeds")
+	}
+}
+
+func TestVariablePoissonDiskTinyRadius(t *testing.T) {
+	// grid of cells as small as minRadius would take exabytes
+	bbox := NewBBox(0, 100, 0, 100)
+	radius := func(v Vertex) float64 { return 5 }
+	sites := utils.VariablePoissonDiskSites(bbox, radius, 1e-9, 10, rand.New(rand.NewSource(23)))
+	if len(sites) < 100 {
+		t.Fatalf("Expected the box filled with sites, got %d", len(sites))
+	}
+	for i, a := range sites {
+		for _, b := range sites[:i] {
+			if d := utils.Distance(a, b); d < 5 {
+				t.Fatalf("Sites %v and %v are only %g apart", a, b, d)
+			}
+		}
+	}
+}
+
+func TestPoissonDiskInvalid(t *testing.T) {
+	bbox := NewBBox(0, 10, 0, 10)
+	one := func(Vertex) float64 { return 1 }
+	for _, radii := range [][2]float64{{0, 1}, {-1, 1}, {math.NaN(), 1}, {2, 1}, {1, math.NaN()}, {1, math.Inf(1)}} {
+		if sites := utils.VariablePoissonDiskSites(bbox, one, radii[0], radii[1], nil); sites != nil {
+			t.Errorf("Expected nil for radii %v, got %d sites", radii, len(sites))
+		}
+	}
+	for _, radius := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		if sites := utils.PoissonDiskSites(bbox, radius, nil); sites != nil {
+			t.Errorf("Expected nil for radius %g, got %d sites", radius, len(sites))
+		}
+	}
+	if sites := utils.PoissonDiskSites(NewBBox(10, 0, 0, 10), 1, nil); sites != nil {
+		t.Errorf("Expected nil for inverted box, got %d sites", len(sites))
+	}
+}
+
+func TestSitesSeed(t *testing.T) {
+	bbox := NewBBox(0, 100, 0, 100)
+	generators := map[string]func(r *rand.Rand) []Vertex{
+		"uniform":  func(r *rand.Rand) []Vertex { return utils.UniformSites(bbox, 50, r) },
+		"jittered": func(r *rand.Rand) []Vertex { return utils.JitteredGridSites(bbox, 8, 6, 0.5, r) },
+		"hex":      func(r *rand.Rand) []Vertex { return utils.HexGridSites(bbox, 10, 0.5, r) },
+	}
+	for name, generate := range generators {
+		sites := generate(rand.New(rand.NewSource(5)))
+		again := generate(rand.New(rand.NewSource(5)))
+		if len(sites) == 0 || len(again) != len(sites) {
+			t.Fatalf("%s: expected the same number of sites, got %d and %d", name, len(sites), len(again))
+		}
+		for i := range sites {
+			if sites[i] != again[i] {
+				t.Fatalf("%s: site %d differs for the same seed: %v and %v", name, i, sites[i], again[i])
+			}
+			if !inBBox(sites[i], bbox) {
+				t.Errorf("%s: site %v is out of the box", name, sites[i])
+			}
+		}
+		if other := generate(rand.New(rand.NewSource(6))); other[0] == sites[0] {
+			t.Errorf("%s: sites are the same for different seeds", name)
+		}
+	}
+}
+
+func TestLowDiscrepancySites(t *testing.T) {
+	bbox := NewBBox(0, 1, 0, 1)
+	if sites := utils.HaltonSites(bbox, 3, 0); sites[0] != (Vertex{0.5, 1.0 / 3}) || sites[1] != (Vertex{0.25, 2.0 / 3}) {
+		t.Errorf("Expected Halton sequence to start with (0.5, 1/3), (0.25, 2/3), got %v", sites)
+	}
+	if sites := utils.SobolSites(bbox, 3, 0); sites[0] != (Vertex{0.5, 0.5}) || sites[1] != (Vertex{0.75, 0.25}) {
+		t.Errorf("Expected Sobol sequence to start with (0.5, 0.5), (0.75, 0.25), got %v", sites)
+	}
+
+	for name, generate := range map[string]func(count, skip int) []Vertex{
+		"halton": func(count, skip int) []Vertex { return utils.HaltonSites(bbox, count, skip) },
+		"sobol":  func(count, skip int) []Vertex { return utils.SobolSites(bbox, count, skip) },
+	} {
+		sites := generate(10, 0)
+		skipped := generate(5, 5)
+		negative := generate(10, -3)
+		for i := range sites {
+			if negative[i] != sites[i] {
+				t.Errorf("%s: negative skip gives %v instead of %v", name, negative[i], sites[i])
+			}
+			if i >= 5 && skipped[i-5] != sites[i] {
+				t.Errorf("%s: skipped site %v instead of %v", name, skipped[i-5], sites[i])
+			}
+		}
+	}
+}
+
+func TestHexGridSpacing(t *testing.T) {
+	bbox := NewBBox(0, 100, 0, 100)
+	sites := utils.HexGridSites(bbox, 10, 0, nil)
+	if len(sites) == 0 {
+		t.Fatal("No sites")
+	}
+	for _, a := range sites {
+		neighbours := 0
+		for _, b := range sites {
+			d := utils.Distance(a, b)
+			if a != b && d < 10-1e-9 {
+				t.Fatalf("Sites %v and %v are only %g apart", a, b, d)
+			}
+			if math.Abs(d-10) < 1e-9 {
+				neighbours++
+			}
+		}
+		// sites away from the border have all six neighbours
+		if a.X > 10 && a.X < 90 && a.Y > 10 && a.Y < 90 && neighbours != 6 {
+			t.Errorf("Site %v has %d neighbours", a, neighbours)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2013 Przemyslaw Szczepaniak.
+// MIT License: See https://github.com/gorhill/Javascript-Voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+// Weighted voronoi stippling of images
+
+package utils
+
+import (
+	"github.com/pzsz/voronoi"
+	"image"
+	"math"
+	"math/rand"
+)
+
+// Return density given by darkness of the image stretched over the
+// bounding box: 1 for black pixels, 0 for white or transparent ones and
+// outside of the image. Top left corner of the image lies in the top
+// left corner of the box.
+func ImageDensity(img image.Image, bbox voronoi.BBox) Density {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	darkness := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			r, g, b, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			// colors are premultiplied, composite them over white
+			luminance := (0.299*float64(r)+0.587*float64(g)+0.114*float64(b))/0xffff + 1 - float64(a)/0xffff
+			darkness[y*w+x] = math.Max(0, math.Min(1, 1-luminance))
+		}
+	}
+
+	sx := float64(w) / (bbox.Xr - bbox.Xl)
+	sy := float64(h) / (bbox.Yb - bbox.Yt)
+	return func(v voronoi.Vertex) float64 {
+		x := math.Floor((v.X - bbox.Xl) * sx)
+		y := math.Floor((v.Y - bbox.Yt) * sy)
+		if !(x >= 0 && x < float64(w) && y >= 0 && y < float64(h)) {
+			return 0
+		}
+		return darkness[int(y)*w+int(x)]
+	}
+}
+
+// Options of stippling
+type StippleOptions struct {
+	// Number of stipple points
+	Count int
+	// Relaxation steps and tolerance, see Relax
+	MaxIterations int
+	Tolerance     float64
+	// Source of random initial points, the global one if nil
+	Rand *rand.Rand
+}
+
+// Place stipple points over the image stretched over the bounding box,
+// by relaxing random points with density given by the image darkness.
+// Returns the points and radii of their dots, which cover as much area as
+// the ink of their cells does, so that dots keep the tone of the image.
+func Stipple(img image.Image, bbox voronoi.BBox, opts StippleOptions) ([]voronoi.Vertex, []float64) {
+	if opts.Count <= 0 {
+		return nil, nil
+	}
+	float64n := float64Source(opts.Rand)
+	density := ImageDensity(img, bbox)
+
+	// initial points follow the density, relative to the darkest pixel,
+	// or are uniform if the image is blank
+	w := bbox.Xr - bbox.Xl
+	h := bbox.Yb - bbox.Yt
+	darkest := 0.0
+	bounds := img.Bounds()
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			v := voronoi.Vertex{bbox.Xl + (float64(x)+0.5)*w/float64(bounds.Dx()), bbox.Yt + (float64(y)+0.5)*h/float64(bounds.Dy())}
+			darkest = math.Max(darkest, density(v))
+		}
+	}
+	sites := make([]voronoi.Vertex, 0, opts.Count)
+	for len(sites) < opts.Count {
+		v := voronoi.Vertex{bbox.Xl + float64n()*w, bbox.Yt + float64n()*h}
+		if darkest == 0 || float64n()*darkest < density(v) {
+			sites = append(sites, v)
+		}
+	}
+
+	sites, diagram, _ := RelaxWithDensity(sites, bbox, density, opts.MaxIterations, opts.Tolerance)
+	radii := make([]float64, len(sites))
+	for i := range sites {
+		mass := CellMass(diagram.CellForSite(i), density, DensitySubdivisions)
+		radii[i] = math.Sqrt(mass / math.Pi)
+	}
+	return sites, radii
+}
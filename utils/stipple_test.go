@@ -0,0 +1,95 @@
+// Copyright 2013 Przemyslaw Szczepaniak.
+// MIT License: See https://github.com/gorhill/Javascript-Voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+
+package utils_test
+
+import (
+	. "github.com/pzsz/voronoi"
+	"github.com/pzsz/voronoi/utils"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+// Image with the left half black and the right one white
+func twoTone(w, h int) image.Image {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x >= w/2 {
+				img.SetGray(x, y, color.Gray{255})
+			}
+		}
+	}
+	return img
+}
+
+func TestStippleBlank(t *testing.T) {
+	bbox := NewBBox(0, 100, 0, 100)
+	white := image.NewGray(image.Rect(0, 0, 8, 8))
+	for i := range white.Pix {
+		white.Pix[i] = 255
+	}
+	// transparent image has no ink either
+	for _, img := range []image.Image{white, image.NewRGBA(image.Rect(0, 0, 8, 8))} {
+		sites, radii := utils.Stipple(img, bbox, utils.StippleOptions{Count: 50, MaxIterations: 5, Rand: rand.New(rand.NewSource(1))})
+		if len(sites) != 50 || len(radii) != 50 {
+			t.Fatalf("Expected 50 sites, got %d and %d radii", len(sites), len(radii))
+		}
+		for i, site := range sites {
+			if site.X < 0 || site.X > 100 || site.Y < 0 || site.Y > 100 {
+				t.Errorf("Site %v is out of the box", site)
+			}
+			if radii[i] != 0 {
+				t.Errorf("Expected no dot on blank image, got radius %g", radii[i])
+			}
+		}
+	}
+}
+
+func TestStippleCount(t *testing.T) {
+	for _, count := range []int{0, -1} {
+		sites, radii := utils.Stipple(twoTone(8, 8), NewBBox(0, 100, 0, 100), utils.StippleOptions{Count: count})
+		if sites != nil || radii != nil {
+			t.Errorf("Expected nil for count %d, got %v and %v", count, sites, radii)
+		}
+	}
+}
+
+func TestStippleSeed(t *testing.T) {
+	bbox := NewBBox(0, 100, 0, 100)
+	img := twoTone(16, 16)
+	stipple := func(seed int64) ([]Vertex, []float64) {
+		return utils.Stipple(img, bbox, utils.StippleOptions{Count: 40, MaxIterations: 5, Rand: rand.New(rand.NewSource(seed))})
+	}
+
+	sites, radii := stipple(7)
+	again, againRadii := stipple(7)
+	for i := range sites {
+		if sites[i] != again[i] || radii[i] != againRadii[i] {
+			t.Fatalf("Stipple %d differs for the same seed: %v and %v", i, sites[i], again[i])
+		}
+	}
+	if other, _ := stipple(8); other[0] == sites[0] {
+		t.Errorf("Stipples are the same for different seeds")
+	}
+}
+
+func TestStippleTwoTone(t *testing.T) {
+	bbox := NewBBox(0, 100, 0, 100)
+	sites, radii := utils.Stipple(twoTone(16, 16), bbox, utils.StippleOptions{Count: 60, MaxIterations: 20, Rand: rand.New(rand.NewSource(3))})
+	if len(sites) != 60 {
+		t.Fatalf("Expected 60 sites, got %d", len(sites))
+	}
+	for i, site := range sites {
+		if site.X > 50 {
+			t.Errorf("Site %v lies on the white half", site)
+		}
+		if radii[i] <= 0 {
+			t.Errorf("Site %v has no dot", site)
+		}
+	}
+}
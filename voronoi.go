@@ -1,15 +1,16 @@
 // MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
 
 // Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
-// Port of Raymond Hill's (rhill@raymondhill.net) javascript implementation 
+// Port of Raymond Hill's (rhill@raymondhill.net) javascript implementation
 // of Steven Forune's algorithm to compute Voronoi diagrams
 
 package voronoi
 
 import "math"
 import "sort"
-import "fmt"
 
+// Voronoi diagram computer. It can be reused to compute many diagrams,
+// recycling memory of the previous ones.
 type Voronoi struct {
 	cells []*Cell
 	edges []*Edge
@@ -19,24 +20,177 @@ type Voronoi struct {
 	beachline        rbTree
 	circleEvents     rbTree
 	firstCircleEvent *circleEvent
+
+	triangles     []delaunayTriangle
+	delaunayEdges [][2]*Cell
+
+	// absolute tolerance of coordinate comparisons
+	epsilon float64
+	// region the diagram is clipped to, nil if unbounded
+	region clipRegion
+	// sites were swept, so triangles and delaunayEdges are complete
+	swept bool
+
+	// objects of the previous diagram, recycled by the next computation
+	beachsectionJunkyard []*Beachsection
+	circleEventJunkyard  []*circleEvent
+	cellJunkyard         []*Cell
+	edgeJunkyard         []*Edge
+	halfedgeJunkyard     []*Halfedge
+	// edges discarded by clipping, recycled after the next reset
+	discardedEdges []*Edge
+	// buffers reused by sweep
+	queue       []int
+	siteCells   []*Cell
+	transitions BeachsectionPtrs
+	// buffers reused by shareVertices
+	vertexParent []int
+	vertexOrder  endpointsByPosition
+	vertexCounts []int
+	vertexEdges  []*Edge
+	vertexCells  []*Cell
+	vertexList   []*EdgeVertex
+}
+
+// Create new reusable Voronoi diagram computer. Zero value of Voronoi
+// works the same.
+func NewVoronoi() *Voronoi {
+	return &Voronoi{
+		cellsMap: make(map[Vertex]*Cell),
+	}
+}
+
+// Forget the last computed diagram and keep its memory for the next
+// computation. The diagram must not be used after that.
+func (s *Voronoi) Reset() {
+	for _, cell := range s.cells {
+		s.halfedgeJunkyard = append(s.halfedgeJunkyard, cell.Halfedges...)
+		s.cellJunkyard = append(s.cellJunkyard, cell)
+		delete(s.cellsMap, cell.Site)
+	}
+	s.edgeJunkyard = append(s.edgeJunkyard, s.edges...)
+	s.edgeJunkyard = append(s.edgeJunkyard, s.discardedEdges...)
+
+	// the sweep leaves some beach sections behind, and everything
+	// if it was interrupted by panic
+	if s.beachline.root != nil {
+		for node := s.beachline.getFirst(s.beachline.root); node != nil; node = node.next {
+			s.beachsectionJunkyard = append(s.beachsectionJunkyard, node.value.(*Beachsection))
+		}
+	}
+	if s.circleEvents.root != nil {
+		for node := s.circleEvents.getFirst(s.circleEvents.root); node != nil; node = node.next {
+			s.circleEventJunkyard = append(s.circleEventJunkyard, node.value.(*circleEvent))
+		}
+	}
+	s.beachline.clear()
+	s.circleEvents.clear()
+	s.firstCircleEvent = nil
+	s.region = nil
+	s.swept = false
+
+	s.cells = s.cells[:0]
+	s.edges = s.edges[:0]
+	s.discardedEdges = s.discardedEdges[:0]
+	s.triangles = s.triangles[:0]
+	s.delaunayEdges = s.delaunayEdges[:0]
+
+	// zero Voronoi is ready to use too
+	if s.cellsMap == nil {
+		s.cellsMap = make(map[Vertex]*Cell)
+	}
+}
+
+// Take a cell from the junkyard or create new one
+func (s *Voronoi) newCell(site Vertex, index int) *Cell {
+	n := len(s.cellJunkyard)
+	if n == 0 {
+		return newCell(site, index)
+	}
+	cell := s.cellJunkyard[n-1]
+	s.cellJunkyard = s.cellJunkyard[:n-1]
+	*cell = Cell{Site: site, Index: index, Halfedges: cell.Halfedges[:0]}
+	return cell
+}
+
+// Take an edge from the junkyard or create new one
+func (s *Voronoi) newEdge(LeftCell, RightCell *Cell) *Edge {
+	n := len(s.edgeJunkyard)
+	if n == 0 {
+		return newEdge(LeftCell, RightCell)
+	}
+	edge := s.edgeJunkyard[n-1]
+	s.edgeJunkyard = s.edgeJunkyard[:n-1]
+	initEdge(edge, LeftCell, RightCell)
+	return edge
+}
+
+// Take a halfedge from the junkyard or create new one
+func (s *Voronoi) newHalfedge(edge *Edge, LeftCell, RightCell *Cell) *Halfedge {
+	n := len(s.halfedgeJunkyard)
+	if n == 0 {
+		return newHalfedge(edge, LeftCell, RightCell)
+	}
+	halfedge := s.halfedgeJunkyard[n-1]
+	s.halfedgeJunkyard = s.halfedgeJunkyard[:n-1]
+	initHalfedge(halfedge, edge, LeftCell, RightCell)
+	return halfedge
+}
+
+// Take a beach section from the junkyard or create new one
+func (s *Voronoi) newBeachsection(site Vertex) *Beachsection {
+	n := len(s.beachsectionJunkyard)
+	if n == 0 {
+		return &Beachsection{site: site}
+	}
+	arc := s.beachsectionJunkyard[n-1]
+	s.beachsectionJunkyard = s.beachsectionJunkyard[:n-1]
+	*arc = Beachsection{site: site}
+	return arc
 }
 
 type Diagram struct {
 	Cells []*Cell
 	Edges []*Edge
-	//	EdgesVertices map[Vertex]EdgeVertex
+	// Vertices where edges meet, each one listed once
+	Vertices []*EdgeVertex
+
+	// Delaunay triangles and edges recorded during the sweep,
+	// before any clipping took place
+	triangles     []delaunayTriangle
+	delaunayEdges [][2]*Cell
+
+	// cells of the input sites, by index
+	siteCells []*Cell
+
+	// absolute tolerance the diagram was computed with
+	epsilon float64
+	// region the diagram is clipped to, nil if unbounded
+	region clipRegion
+	// triangles and delaunayEdges come from the sweep, so they are complete
+	swept bool
+}
+
+// Return cell of the i-th site passed to ComputeDiagram. Duplicate sites
+// are merged into a single cell, which is the one of the first duplicate
+// (so its Index is the lowest among them). Returns nil if i is out of range.
+func (d *Diagram) CellForSite(i int) *Cell {
+	if i < 0 || i >= len(d.siteCells) {
+		return nil
+	}
+	return d.siteCells[i]
 }
 
 func (s *Voronoi) getCell(site Vertex) *Cell {
 	ret := s.cellsMap[site]
 	if ret == nil {
-		panic(fmt.Sprintf("Couldn't find cell for site %v", site))
+		panic(&CellNotFoundError{site})
 	}
 	return ret
 }
 
 func (s *Voronoi) createEdge(LeftCell, RightCell *Cell, va, vb Vertex) *Edge {
-	edge := newEdge(LeftCell, RightCell)
+	edge := s.newEdge(LeftCell, RightCell)
 	s.edges = append(s.edges, edge)
 	if va != NO_VERTEX {
 		s.setEdgeStartpoint(edge, LeftCell, RightCell, va)
@@ -49,15 +203,17 @@ func (s *Voronoi) createEdge(LeftCell, RightCell *Cell, va, vb Vertex) *Edge {
 	lCell := LeftCell
 	rCell := RightCell
 
-	lCell.Halfedges = append(lCell.Halfedges, newHalfedge(edge, LeftCell, RightCell))
-	rCell.Halfedges = append(rCell.Halfedges, newHalfedge(edge, RightCell, LeftCell))
+	lCell.Halfedges = append(lCell.Halfedges, s.newHalfedge(edge, LeftCell, RightCell))
+	rCell.Halfedges = append(rCell.Halfedges, s.newHalfedge(edge, RightCell, LeftCell))
 	return edge
 }
 
-func (s *Voronoi) createBorderEdge(LeftCell *Cell, va, vb Vertex) *Edge {
-	edge := newEdge(LeftCell, nil)
+func (s *Voronoi) createBorderEdge(LeftCell *Cell, va, vb Vertex, side int) *Edge {
+	edge := s.newEdge(LeftCell, nil)
 	edge.Va.Vertex = va
 	edge.Vb.Vertex = vb
+	edge.Border = true
+	edge.BorderSide = side
 
 	s.edges = append(s.edges, edge)
 	return edge
@@ -147,6 +303,7 @@ func rightBreakPoint(arc *Beachsection, directrix float64) float64 {
 func (s *Voronoi) detachBeachsection(arc *Beachsection) {
 	s.detachCircleEvent(arc)
 	s.beachline.removeNode(arc.node)
+	s.beachsectionJunkyard = append(s.beachsectionJunkyard, arc)
 }
 
 type BeachsectionPtrs []*Beachsection
@@ -170,7 +327,7 @@ func (s *Voronoi) removeBeachsection(beachsection *Beachsection) {
 	vertex := Vertex{x, y}
 	previous := beachsection.node.previous
 	next := beachsection.node.next
-	disappearingTransitions := BeachsectionPtrs{beachsection}
+	disappearingTransitions := append(s.transitions[:0], beachsection)
 	abs_fn := math.Abs
 
 	// remove collapsed beachsection from beachline
@@ -185,11 +342,23 @@ func (s *Voronoi) removeBeachsection(beachsection *Beachsection) {
 	// beach sections on the beachline, since they obviously are unconstrained
 	// on their left/right side.
 
+	// sections collapsing at the same point have sites on the same circle,
+	// test it exactly, apart from comparing vertex positions
+	circleLeft := previous.value.(*Beachsection).site
+	circleRight := next.value.(*Beachsection).site
+	cocircular := func(site Vertex) bool {
+		if orient2d(circleLeft, beachsection.site, circleRight) > 0 {
+			return incircle(circleLeft, beachsection.site, circleRight, site) == 0
+		}
+		return incircle(circleRight, beachsection.site, circleLeft, site) == 0
+	}
+
 	// look left
 	lArc := previous.value.(*Beachsection)
 	for lArc.circleEvent != nil &&
-		abs_fn(x-lArc.circleEvent.x) < 1e-9 &&
-		abs_fn(y-lArc.circleEvent.ycenter) < 1e-9 {
+		((abs_fn(x-lArc.circleEvent.x) < s.epsilon &&
+			abs_fn(y-lArc.circleEvent.ycenter) < s.epsilon) ||
+			cocircular(lArc.node.previous.value.(*Beachsection).site)) {
 
 		previous = lArc.node.previous
 		disappearingTransitions.appendLeft(lArc)
@@ -206,8 +375,9 @@ func (s *Voronoi) removeBeachsection(beachsection *Beachsection) {
 	// look right
 	var rArc = next.value.(*Beachsection)
 	for rArc.circleEvent != nil &&
-		abs_fn(x-rArc.circleEvent.x) < 1e-9 &&
-		abs_fn(y-rArc.circleEvent.ycenter) < 1e-9 {
+		((abs_fn(x-rArc.circleEvent.x) < s.epsilon &&
+			abs_fn(y-rArc.circleEvent.ycenter) < s.epsilon) ||
+			cocircular(rArc.node.next.value.(*Beachsection).site)) {
 		next = rArc.node.next
 		disappearingTransitions.appendRight(rArc)
 		s.detachBeachsection(rArc) // mark for reuse
@@ -233,6 +403,17 @@ func (s *Voronoi) removeBeachsection(beachsection *Beachsection) {
 		s.setEdgeStartpoint(rArc.edge, lSite, rSite, vertex)
 	}
 
+	// sites of all the beach sections meeting at the vertex lie on the
+	// same circle, which is a Delaunay polygon: record it as a fan of
+	// triangles
+	firstCell := s.getCell(disappearingTransitions[0].site)
+	for iArc := 2; iArc < nArcs; iArc++ {
+		s.addTriangle(firstCell,
+			s.getCell(disappearingTransitions[iArc-1].site),
+			s.getCell(disappearingTransitions[iArc].site),
+			vertex)
+	}
+
 	// create a new edge as we have now a new transition between
 	// two beach sections which were previously not adjacent.
 	// since this edge appears as a new vertex is defined, the vertex
@@ -249,6 +430,7 @@ func (s *Voronoi) removeBeachsection(beachsection *Beachsection) {
 	// adjacent to collapsed sections
 	s.attachCircleEvent(lArc)
 	s.attachCircleEvent(rArc)
+	s.transitions = disappearingTransitions
 }
 
 func (s *Voronoi) addBeachsection(site Vertex) {
@@ -261,13 +443,14 @@ func (s *Voronoi) addBeachsection(site Vertex) {
 	// hence we expand in-place the comparison-against-epsilon calls.
 	var lNode, rNode *rbNode
 	var dxl, dxr float64
+	epsilon := s.epsilon
 	node := s.beachline.root
 
 	for node != nil {
 		nodeBeachline := node.value.(*Beachsection)
 		dxl = leftBreakPoint(nodeBeachline, directrix) - x
 		// x lessThanWithEpsilon xl => falls somewhere before the left edge of the beachsection
-		if dxl > 1e-9 {
+		if dxl > epsilon {
 			// this case should never happen
 			// if (!node.rbLeft) {
 			//    rNode = node.rbLeft;
@@ -277,7 +460,7 @@ func (s *Voronoi) addBeachsection(site Vertex) {
 		} else {
 			dxr = x - rightBreakPoint(nodeBeachline, directrix)
 			// x greaterThanWithEpsilon xr => falls somewhere after the right edge of the beachsection
-			if dxr > 1e-9 {
+			if dxr > epsilon {
 				if node.right == nil {
 					lNode = node
 					break
@@ -285,10 +468,10 @@ func (s *Voronoi) addBeachsection(site Vertex) {
 				node = node.right
 			} else {
 				// x equalWithEpsilon xl => falls exactly on the left edge of the beachsection
-				if dxl > -1e-9 {
+				if dxl > -epsilon {
 					lNode = node.previous
 					rNode = node
-				} else if dxr > -1e-9 {
+				} else if dxr > -epsilon {
 					// x equalWithEpsilon xr => falls exactly on the right edge of the beachsection
 					lNode = node
 					rNode = node.next
@@ -315,7 +498,7 @@ func (s *Voronoi) addBeachsection(site Vertex) {
 	// undefined or null.
 
 	// create a new beach section object for the site and add it to RB-tree
-	newArc := &Beachsection{site: site}
+	newArc := s.newBeachsection(site)
 	if lArc == nil {
 		s.beachline.insertSuccessor(nil, newArc)
 	} else {
@@ -348,7 +531,7 @@ func (s *Voronoi) addBeachsection(site Vertex) {
 		s.detachCircleEvent(lArc)
 
 		// split the beach section into two separate beach sections
-		rArc = &Beachsection{site: lArc.site}
+		rArc = s.newBeachsection(lArc.site)
 		s.beachline.insertSuccessor(newArc.node, rArc)
 
 		// since we have a new transition between two beach sections,
@@ -432,6 +615,7 @@ func (s *Voronoi) addBeachsection(site Vertex) {
 
 		// one transition disappear
 		s.setEdgeStartpoint(rArc.edge, lCell, rCell, vertex)
+		s.addTriangle(lCell, cell, rCell, vertex)
 
 		// two new transitions appear at the new vertex location
 		newArc.edge = s.createEdge(lCell, cell, NO_VERTEX, vertex)
@@ -499,10 +683,10 @@ func (s *Voronoi) attachCircleEvent(arc *Beachsection) {
 	// collapse, hence it can't end up as a vertex (we reuse 'd' here, which
 	// sign is reverse of the orientation, hence we reverse the test.
 	// http://en.wikipedia.org/wiki/Curve_orientation#Orientation_of_a_simple_polygon
-	// rhill 2011-05-21: Nasty finite precision error which caused circumcircle() to
-	// return infinites: 1e-12 seems to fix the problem.
-	d := 2 * (ax*cy - ay*cx)
-	if d >= -2e-12 {
+	// The orientation is tested exactly, nearly collinear sites would give
+	// the wrong answer due to finite precision errors.
+	d := 2 * orient2d(LeftSite, RightSite, cSite)
+	if d >= 0 {
 		return
 	}
 
@@ -515,8 +699,15 @@ func (s *Voronoi) attachCircleEvent(arc *Beachsection) {
 	// Important: ybottom should always be under or at sweep, so no need
 	// to waste CPU cycles by checking
 
-	// recycle circle event object if possible	
-	circleEventInst := &circleEvent{
+	// recycle circle event object if possible
+	var circleEventInst *circleEvent
+	if n := len(s.circleEventJunkyard); n > 0 {
+		circleEventInst = s.circleEventJunkyard[n-1]
+		s.circleEventJunkyard = s.circleEventJunkyard[:n-1]
+	} else {
+		circleEventInst = &circleEvent{}
+	}
+	*circleEventInst = circleEvent{
 		arc:     arc,
 		site:    cSite,
 		x:       x + bx,
@@ -565,6 +756,7 @@ func (s *Voronoi) detachCircleEvent(arc *Beachsection) {
 			}
 		}
 		s.circleEvents.removeNode(circle.node) // remove from RB-tree
+		s.circleEventJunkyard = append(s.circleEventJunkyard, circle)
 		arc.circleEvent = nil
 	}
 }
@@ -579,12 +771,47 @@ func NewBBox(xl, xr, yt, yb float64) BBox {
 	return BBox{xl, xr, yt, yb}
 }
 
+// Region diagrams are clipped to
+type clipRegion interface {
+	// connect dangling end of the edge to the region boundary
+	connectEdge(edge *Edge, epsilon float64) bool
+	// cut edge to the part inside of the region
+	clipEdge(edge *Edge) bool
+	// add edges along the boundary to close cells
+	closeCells(s *Voronoi)
+	contains(v Vertex) bool
+	// tells if the vertex lies on the boundary
+	onBoundary(v Vertex, epsilon float64) bool
+}
+
+func (bbox BBox) connectEdge(edge *Edge, epsilon float64) bool {
+	return connectEdge(edge, bbox, epsilon)
+}
+
+func (bbox BBox) clipEdge(edge *Edge) bool {
+	return clipEdge(edge, bbox)
+}
+
+func (bbox BBox) closeCells(s *Voronoi) {
+	s.closeCells(bbox)
+}
+
+func (bbox BBox) contains(v Vertex) bool {
+	return v.X >= bbox.Xl && v.X <= bbox.Xr && v.Y >= bbox.Yt && v.Y <= bbox.Yb
+}
+
+func (bbox BBox) onBoundary(v Vertex, epsilon float64) bool {
+	return equalWithEpsilon(v.X, bbox.Xl, epsilon) || equalWithEpsilon(v.X, bbox.Xr, epsilon) ||
+		equalWithEpsilon(v.Y, bbox.Yt, epsilon) || equalWithEpsilon(v.Y, bbox.Yb, epsilon)
+}
+
 // connect dangling edges (not if a cursory test tells us
 // it is not going to be visible.
 // return value:
-//   false: the dangling endpoint couldn't be connected
-//   true: the dangling endpoint could be connected
-func connectEdge(edge *Edge, bbox BBox) bool {
+//
+//	false: the dangling endpoint couldn't be connected
+//	true: the dangling endpoint could be connected
+func connectEdge(edge *Edge, bbox BBox, epsilon float64) bool {
 	// skip if end point already connected
 	vb := edge.Vb.Vertex
 	if vb != NO_VERTEX {
@@ -609,7 +836,7 @@ func connectEdge(edge *Edge, bbox BBox) bool {
 	var fm, fb float64
 
 	// get the line equation of the bisector if line is not vertical
-	if !equalWithEpsilon(ry, ly) {
+	if !equalWithEpsilon(ry, ly, epsilon) {
 		fm = (lx - rx) / (ry - ly)
 		fb = fy - fm*fx
 	}
@@ -627,7 +854,7 @@ func connectEdge(edge *Edge, bbox BBox) bool {
 	// bounding box to use to determine a reasonable start point
 
 	// special case: vertical line
-	if equalWithEpsilon(ry, ly) {
+	if equalWithEpsilon(ry, ly, epsilon) {
 		// doesn't intersect with viewport
 		if fx < xl || fx >= xr {
 			return false
@@ -696,8 +923,10 @@ func connectEdge(edge *Edge, bbox BBox) bool {
 }
 
 // line-clipping code taken from:
-//   Liang-Barsky function by Daniel White
-//   http://www.skytopia.com/project/articles/compsci/clipping.html
+//
+//	Liang-Barsky function by Daniel White
+//	http://www.skytopia.com/project/articles/compsci/clipping.html
+//
 // Thanks!
 // A bit modified to minimize code paths
 func clipEdge(edge *Edge, bbox BBox) bool {
@@ -768,7 +997,7 @@ func clipEdge(edge *Edge, bbox BBox) bool {
 			t0 = r
 		}
 	}
-	// bottom        
+	// bottom
 	q = bbox.Yb - ay
 	if dy == 0 && q < 0 {
 		return false
@@ -809,23 +1038,24 @@ func clipEdge(edge *Edge, bbox BBox) bool {
 	return true
 }
 
-func equalWithEpsilon(a, b float64) bool {
-	return math.Abs(a-b) < 1e-9
+func equalWithEpsilon(a, b, epsilon float64) bool {
+	return math.Abs(a-b) < epsilon
 }
 
-func lessThanWithEpsilon(a, b float64) bool {
-	return b-a > 1e-9
+func lessThanWithEpsilon(a, b, epsilon float64) bool {
+	return b-a > epsilon
 }
 
-func greaterThanWithEpsilon(a, b float64) bool {
-	return a-b > 1e-9
+func greaterThanWithEpsilon(a, b, epsilon float64) bool {
+	return a-b > epsilon
 }
 
-// Connect/cut edges at bounding box
-func (s *Voronoi) clipEdges(bbox BBox) {
+// Connect/cut edges at clipping region
+func (s *Voronoi) clipEdges(region clipRegion) {
 	// connect all dangling edges to bounding box
 	// or get rid of them if it can't be done
 	abs_fn := math.Abs
+	epsilon := s.epsilon
 
 	// iterate backward so we can splice safely
 	for i := len(s.edges) - 1; i >= 0; i-- {
@@ -833,9 +1063,10 @@ func (s *Voronoi) clipEdges(bbox BBox) {
 		// edge is removed if:
 		//   it is wholly outside the bounding box
 		//   it is actually a point rather than a line
-		if !connectEdge(edge, bbox) || !clipEdge(edge, bbox) || (abs_fn(edge.Va.X-edge.Vb.X) < 1e-9 && abs_fn(edge.Va.Y-edge.Vb.Y) < 1e-9) {
+		if !region.connectEdge(edge, epsilon) || !region.clipEdge(edge) || (abs_fn(edge.Va.X-edge.Vb.X) < epsilon && abs_fn(edge.Va.Y-edge.Vb.Y) < epsilon) {
 			edge.Va.Vertex = NO_VERTEX
 			edge.Vb.Vertex = NO_VERTEX
+			s.discardedEdges = append(s.discardedEdges, edge)
 			s.edges[i] = s.edges[len(s.edges)-1]
 			s.edges = s.edges[0 : len(s.edges)-1]
 		}
@@ -851,10 +1082,15 @@ func (s *Voronoi) closeCells(bbox BBox) {
 	yb := bbox.Yb
 	cells := s.cells
 	abs_fn := math.Abs
+	epsilon := s.epsilon
 
 	for _, cell := range cells {
 		// trim non fully-defined halfedges and sort them counterclockwise
 		if cell.prepare() == 0 {
+			// special case: only one site, in which case, the viewport is the cell
+			if len(cells) == 1 {
+				s.closeRegionCell(cell, bbox.Polygon())
+			}
 			continue
 		}
 
@@ -865,9 +1101,6 @@ func (s *Voronoi) closeCells(bbox BBox) {
 		halfedges := cell.Halfedges
 		nHalfedges := len(halfedges)
 
-		// special case: only one site, in which case, the viewport is the cell
-		// ...
-		// all other cases
 		iLeft := 0
 		for iLeft < nHalfedges {
 			iRight := (iLeft + 1) % nHalfedges
@@ -875,53 +1108,66 @@ func (s *Voronoi) closeCells(bbox BBox) {
 			startpoint := halfedges[iRight].GetStartpoint()
 			// if end point is not equal to start point, we need to add the missing
 			// halfedge(s) to close the cell
-			if abs_fn(endpoint.X-startpoint.X) >= 1e-9 || abs_fn(endpoint.Y-startpoint.Y) >= 1e-9 {
+			if abs_fn(endpoint.X-startpoint.X) >= epsilon || abs_fn(endpoint.Y-startpoint.Y) >= epsilon {
+				// walk would never reach the next halfedge
+				if !bbox.onBoundary(startpoint, epsilon) {
+					cell.Open = true
+					break
+				}
 				// if we reach this point, cell needs to be closed by walking
 				// counterclockwise along the bounding box until it connects
 				// to next halfedge in the list
 				va := endpoint
 				vb := endpoint
+				var side int
 				// walk downward along left side
-				if equalWithEpsilon(endpoint.X, xl) && lessThanWithEpsilon(endpoint.Y, yb) {
-					if equalWithEpsilon(startpoint.X, xl) {
+				if equalWithEpsilon(endpoint.X, xl, epsilon) && lessThanWithEpsilon(endpoint.Y, yb, epsilon) {
+					side = LeftSide
+					if equalWithEpsilon(startpoint.X, xl, epsilon) {
 						vb = Vertex{xl, startpoint.Y}
 					} else {
 						vb = Vertex{xl, yb}
 					}
 
 					// walk rightward along bottom side
-				} else if equalWithEpsilon(endpoint.Y, yb) && lessThanWithEpsilon(endpoint.X, xr) {
-					if equalWithEpsilon(startpoint.Y, yb) {
+				} else if equalWithEpsilon(endpoint.Y, yb, epsilon) && lessThanWithEpsilon(endpoint.X, xr, epsilon) {
+					side = BottomSide
+					if equalWithEpsilon(startpoint.Y, yb, epsilon) {
 						vb = Vertex{startpoint.X, yb}
 					} else {
 						vb = Vertex{xr, yb}
 					}
 					// walk upward along right side
-				} else if equalWithEpsilon(endpoint.X, xr) && greaterThanWithEpsilon(endpoint.Y, yt) {
-					if equalWithEpsilon(startpoint.X, xr) {
+				} else if equalWithEpsilon(endpoint.X, xr, epsilon) && greaterThanWithEpsilon(endpoint.Y, yt, epsilon) {
+					side = RightSide
+					if equalWithEpsilon(startpoint.X, xr, epsilon) {
 						vb = Vertex{xr, startpoint.Y}
 					} else {
 						vb = Vertex{xr, yt}
 					}
 					// walk leftward along top side
-				} else if equalWithEpsilon(endpoint.Y, yt) && greaterThanWithEpsilon(endpoint.X, xl) {
-					if equalWithEpsilon(startpoint.Y, yt) {
+				} else if equalWithEpsilon(endpoint.Y, yt, epsilon) && greaterThanWithEpsilon(endpoint.X, xl, epsilon) {
+					side = TopSide
+					if equalWithEpsilon(startpoint.Y, yt, epsilon) {
 						vb = Vertex{startpoint.X, yt}
 					} else {
 						vb = Vertex{xl, yt}
 					}
 				} else {
-					//			break
+					// dangling end point not on the bounding box within
+					// tolerance, cell can't be closed
+					cell.Open = true
+					break
 				}
 
 				// Create new border edge. Slide it into iLeft+1 position
-				edge := s.createBorderEdge(cell, va, vb)
+				edge := s.createBorderEdge(cell, va, vb, side)
 				cell.Halfedges = append(cell.Halfedges, nil)
 				halfedges = cell.Halfedges
 				nHalfedges = len(halfedges)
 
 				copy(halfedges[iLeft+2:len(halfedges)], halfedges[iLeft+1:len(halfedges)-1])
-				halfedges[iLeft+1] = newHalfedge(edge, cell, nil)
+				halfedges[iLeft+1] = s.newHalfedge(edge, cell, nil)
 
 			}
 			iLeft++
@@ -929,54 +1175,214 @@ func (s *Voronoi) closeCells(bbox BBox) {
 	}
 }
 
-func (s *Voronoi) gatherVertexEdges() {
-	vertexEdgeMap := make(map[Vertex][]*Edge)
+// Make edges meeting in a vertex share it, and collect edges of every
+// vertex. End points closer than the tolerance where halfedges of a cell
+// meet are the same vertex, located at one of them.
+func (s *Voronoi) shareVertices() {
+	// end points which are vertices get ids, 2*i for Va of the i-th edge
+	// and 2*i+1 for Vb, kept in Index until vertices are numbered
+	n := 2 * len(s.edges)
+	parent := s.vertexParent[:0]
+	order := s.vertexOrder[:0]
+	if cap(parent) < n {
+		parent = make([]int, 0, n)
+		order = make(endpointsByPosition, 0, n)
+	}
+	for i, edge := range s.edges {
+		for k, v := range [2]*EdgeVertex{edge.Va, edge.Vb} {
+			id := 2*i + k
+			parent = append(parent, id)
+			if isVertex(edge, v) {
+				v.Index = id
+				order = append(order, endpointKey{v.Vertex, id})
+			}
+		}
+	}
+	find := func(i int) int {
+		for parent[i] != i {
+			parent[i] = parent[parent[i]]
+			i = parent[i]
+		}
+		return i
+	}
 
-	for _, edge := range s.edges {
-		vertexEdgeMap[edge.Va.Vertex] = append(
-			vertexEdgeMap[edge.Va.Vertex], edge)
-		vertexEdgeMap[edge.Vb.Vertex] = append(
-			vertexEdgeMap[edge.Vb.Vertex], edge)
+	// equal end points are next to each other in sorted order, the first
+	// one of them stands for the rest
+	sort.Sort(order)
+	for k := 1; k < len(order); k++ {
+		if order[k].Vertex == order[k-1].Vertex {
+			parent[order[k].id] = find(order[k-1].id)
+		}
+	}
+	for _, cell := range s.cells {
+		halfedges := cell.Halfedges
+		for k, halfedge := range halfedges {
+			next := halfedges[(k+1)%len(halfedges)]
+			end := halfedge.GetEndVertex()
+			start := next.GetStartVertex()
+			if isVertex(halfedge.Edge, end) && isVertex(next.Edge, start) &&
+				end.Vertex != start.Vertex && equalVertices(end.Vertex, start.Vertex, s.epsilon) {
+				if a, b := find(end.Index), find(start.Index); a != b {
+					parent[a] = b
+				}
+			}
+		}
+	}
+	s.vertexParent = parent
+	s.vertexOrder = order
+
+	// number vertices in order of edges, the end point standing for the
+	// others becomes the vertex, and count its edges so that slices of
+	// edges can be cut from a single buffer
+	// vertices are shared by two end points at least
+	vertices := s.vertexList[:0]
+	counts := s.vertexCounts[:0]
+	if cap(vertices) < len(order)/2 {
+		vertices = make([]*EdgeVertex, 0, len(order)/2)
+		counts = make([]int, 0, len(order)/2)
+	}
+	for i, edge := range s.edges {
+		for k, v := range [2]*EdgeVertex{edge.Va, edge.Vb} {
+			if !isVertex(edge, v) {
+				continue
+			}
+			// Index of the root is still its id until it gets a number
+			root := s.endpoint(find(2*i + k))
+			if j := root.Index; j < len(vertices) && vertices[j] == root {
+				counts[j]++
+				continue
+			}
+			root.Index = len(vertices)
+			vertices = append(vertices, root)
+			counts = append(counts, 1)
+		}
 	}
 
-	for vertex, edgeSlice := range vertexEdgeMap {
-		for _, edge := range edgeSlice {
-			if vertex == edge.Va.Vertex {
-				edge.Va.Edges = edgeSlice
+	// turn counts into positions in the buffer, where the edges of
+	// the vertex end
+	total := 0
+	for i, c := range counts {
+		total += c
+		counts[i] = total
+	}
+	s.vertexCounts = counts
+	buffer := s.vertexEdges[:0]
+	if cap(buffer) < total {
+		buffer = make([]*Edge, 0, total)
+	}
+	buffer = buffer[:total]
+	s.vertexEdges = buffer
+
+	// share vertices and fill the buffer backwards, keeping the order of
+	// edges, which moves positions to where the edges of the vertex start
+	for i := len(s.edges) - 1; i >= 0; i-- {
+		edge := s.edges[i]
+		for k := 1; k >= 0; k-- {
+			v := &edge.Va
+			if k == 1 {
+				v = &edge.Vb
 			}
-			if vertex == edge.Vb.Vertex {
-				edge.Vb.Edges = edgeSlice
+			if !isVertex(edge, *v) {
+				continue
 			}
+			root := s.endpoint(find(2*i + k))
+			counts[root.Index]--
+			buffer[counts[root.Index]] = edge
+			*v = root
+		}
+	}
+
+	// edges of a vertex end where the edges of the next one start, slices
+	// are capped not to overwrite the next one on append
+	for i, vertex := range vertices {
+		end := total
+		if i+1 < len(counts) {
+			end = counts[i+1]
 		}
+		vertex.Edges = buffer[counts[i]:end:end]
 	}
+	s.vertexList = vertices
 }
 
-// Compute voronoi diagram. If closeCells == true, edges from bounding box will be 
-// included in diagram.
+// End point of an edge by its id, see shareVertices. Vertices aren't
+// shared yet, so it is the edge's own one.
+func (s *Voronoi) endpoint(id int) *EdgeVertex {
+	edge := s.edges[id/2]
+	if id%2 == 0 {
+		return edge.Va
+	}
+	return edge.Vb
+}
+
+// End point of an edge with its id, for sorting them by position
+type endpointKey struct {
+	Vertex
+	id int
+}
+
+type endpointsByPosition []endpointKey
+
+func (s endpointsByPosition) Len() int      { return len(s) }
+func (s endpointsByPosition) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s endpointsByPosition) Less(i, j int) bool {
+	return s[i].X < s[j].X || (s[i].X == s[j].X && s[i].Y < s[j].Y)
+}
+
+// Compute voronoi diagram. If closeCells == true, edges from bounding box will be
+// included in diagram. Sites slice is not modified, cells are returned in
+// the order of their sites.
 func ComputeDiagram(sites []Vertex, bbox BBox, closeCells bool) *Diagram {
-	s := &Voronoi{
-		cellsMap: make(map[Vertex]*Cell),
+	return NewVoronoi().Compute(sites, bbox, closeCells)
+}
+
+// Compute voronoi diagram like ComputeDiagram, reusing memory of the
+// previously computed one. The previous diagram must not be used anymore.
+func (s *Voronoi) Compute(sites []Vertex, bbox BBox, closeCells bool) *Diagram {
+	s.Reset()
+	s.epsilon = tolerance(DefaultTolerance, bbox.Polygon().Vertices)
+
+	siteCells := s.sweep(sites)
+	s.clip(bbox, closeCells)
+	return s.diagram(siteCells)
+}
+
+// Run Fortune's sweep over the sites. Returns cell of every site.
+func (s *Voronoi) sweep(sites []Vertex) []*Cell {
+	// Initialize site event queue, leaving the caller's slice untouched.
+	// Sites are processed from top to bottom and left to right, stable
+	// sort keeps duplicates in input order.
+	queue := s.queue[:0]
+	for i := range sites {
+		queue = append(queue, i)
 	}
+	s.queue = queue
+	sort.Stable(siteQueue{sites, queue})
 
-	// Initialize site event queue
-	sort.Sort(VerticesByY{sites})
+	// cell of every input site, duplicates share the cell of the first one
+	siteCells := s.siteCells[:0]
+	for range sites {
+		siteCells = append(siteCells, nil)
+	}
+	s.siteCells = siteCells
 
-	pop := func() *Vertex {
-		if len(sites) == 0 {
-			return nil
+	pop := func() (*Vertex, int) {
+		if len(queue) == 0 {
+			return nil, -1
 		}
 
-		site := sites[0]
-		sites = sites[1:]
-		return &site
+		index := queue[0]
+		queue = queue[1:]
+		// points into the caller's slice, which is only read
+		return &sites[index], index
 	}
 
-	site := pop()
+	site, siteIndex := pop()
 
 	// process queue
 	xsitex := math.SmallestNonzeroFloat64
 	xsitey := math.SmallestNonzeroFloat64
 	var circle *circleEvent
+	var lastCell *Cell
 
 	// main loop
 	for {
@@ -990,16 +1396,17 @@ func ComputeDiagram(sites []Vertex, bbox BBox, closeCells bool) *Diagram {
 			// only if site is not a duplicate
 			if site.X != xsitex || site.Y != xsitey {
 				// first create cell for new site
-				nCell := newCell(*site)
-				s.cells = append(s.cells, nCell)
-				s.cellsMap[*site] = nCell
+				lastCell = s.newCell(*site, siteIndex)
+				s.cells = append(s.cells, lastCell)
+				s.cellsMap[*site] = lastCell
 				// then create a beachsection for that site
 				s.addBeachsection(*site)
 				// remember last site coords to detect duplicate
 				xsitey = site.Y
 				xsitex = site.X
 			}
-			site = pop()
+			siteCells[siteIndex] = lastCell
+			site, siteIndex = pop()
 			// remove beach section
 		} else if circle != nil {
 			s.removeBeachsection(circle.arc)
@@ -1009,27 +1416,249 @@ func ComputeDiagram(sites []Vertex, bbox BBox, closeCells bool) *Diagram {
 		}
 	}
 
+	// every edge created during the sweep separates two Delaunay
+	// neighbours, remember them before clipping discards any
+	s.swept = true
+	for _, edge := range s.edges {
+		s.delaunayEdges = append(s.delaunayEdges, [2]*Cell{edge.LeftCell, edge.RightCell})
+	}
+
+	return siteCells
+}
+
+// Clip edges to the region, optionally closing cells along its boundary
+func (s *Voronoi) clip(region clipRegion, closeCells bool) {
+	s.region = region
+	s.markClippedTriangles(region)
+
 	// wrapping-up:
 	//   connect dangling edges to bounding box
 	//   cut edges as per bounding box
 	//   discard edges completely outside bounding box
 	//   discard edges which are point-like
-	s.clipEdges(bbox)
+	s.clipEdges(region)
 
 	//   add missing edges in order to close opened cells
 	if closeCells {
-		s.closeCells(bbox)
+		region.closeCells(s)
 	} else {
 		for _, cell := range s.cells {
 			cell.prepare()
 		}
 	}
 
-	s.gatherVertexEdges()
+	for _, cell := range s.cells {
+		// only cell is the whole region, with or without halfedges
+		if len(s.cells) == 1 {
+			cell.OnBorder = true
+			break
+		}
+		for _, halfedge := range cell.Halfedges {
+			edge := halfedge.Edge
+			if edge.Border || region.onBoundary(edge.Va.Vertex, s.epsilon) || region.onBoundary(edge.Vb.Vertex, s.epsilon) {
+				cell.OnBorder = true
+				break
+			}
+		}
+	}
+}
+
+// Gather results of computation into a diagram
+func (s *Voronoi) diagram(siteCells []*Cell) *Diagram {
+	s.shareVertices()
+	linkHalfedges(s.cells)
+	// cells around a vertex of closed cells are at most as many as its
+	// edges, open ones can grow the buffer
+	cells := s.vertexCells[:0]
+	if cap(cells) < len(s.vertexEdges) {
+		cells = make([]*Cell, 0, len(s.vertexEdges))
+	}
+	vertices := s.vertexList
+	for _, vertex := range vertices {
+		vertex.pickHalfedge()
+		start := len(cells)
+		cells = vertex.gatherCells(cells)
+		vertex.Cells = cells[start:len(cells):len(cells)]
+	}
+	s.vertexCells = cells
+
+	// return cells in order of input sites
+	sort.Sort(cellsByIndex(s.cells))
 
 	result := &Diagram{
-		Edges: s.edges,
-		Cells: s.cells,
+		Edges:         s.edges,
+		Cells:         s.cells,
+		Vertices:      vertices,
+		siteCells:     siteCells,
+		triangles:     s.triangles,
+		delaunayEdges: s.delaunayEdges,
+		epsilon:       s.epsilon,
+		region:        s.region,
+		swept:         s.swept,
 	}
 	return result
 }
+
+// Compute voronoi diagram like ComputeDiagram, but validate sites and
+// bounding box first and report errors instead of panicking.
+func ComputeDiagramE(sites []Vertex, bbox BBox, closeCells bool) (*Diagram, error) {
+	return ComputeDiagramWithOptions(sites, Options{
+		BBox:       bbox,
+		CloseCells: closeCells,
+	})
+}
+
+// Options of diagram computation
+type Options struct {
+	// Bounding box the diagram is clipped to, unless Region is set
+	BBox BBox
+	// Convex polygon the diagram is clipped to instead of BBox. Its
+	// vertices can go either way, ErrNotConvex is returned if they don't
+	// form a strictly convex polygon.
+	Region *ConvexPolygon
+	// Add edges along the clipping region to close cells
+	CloseCells bool
+	// Don't clip the diagram at all, return infinite edges as rays and
+	// lines instead. BBox, Region and CloseCells are ignored.
+	Unbounded bool
+	// Tolerance of coordinate comparisons, relative to the size of the
+	// clipping region (or of the sites bounding box, for unbounded
+	// diagrams). Zero means DefaultTolerance.
+	Tolerance float64
+}
+
+// Tolerance used when Options.Tolerance is zero, and by ComputeDiagram
+const DefaultTolerance = 1e-9
+
+// Turn relative tolerance into absolute one, scaled by the larger
+// dimension of the bounding box of the vertices
+func tolerance(relative float64, vertices []Vertex) float64 {
+	if len(vertices) == 0 {
+		return relative
+	}
+	xl, xr := vertices[0].X, vertices[0].X
+	yt, yb := vertices[0].Y, vertices[0].Y
+	for _, v := range vertices {
+		xl = math.Min(xl, v.X)
+		xr = math.Max(xr, v.X)
+		yt = math.Min(yt, v.Y)
+		yb = math.Max(yb, v.Y)
+	}
+	scale := math.Max(xr-xl, yb-yt)
+	if scale == 0 {
+		scale = 1
+	}
+	return relative * scale
+}
+
+// Absolute tolerance for the options
+func (opts *Options) epsilon(sites []Vertex) float64 {
+	relative := opts.Tolerance
+	if relative == 0 {
+		relative = DefaultTolerance
+	}
+	switch {
+	case opts.Unbounded:
+		return tolerance(relative, sites)
+	case opts.Region != nil:
+		return tolerance(relative, opts.Region.Vertices)
+	}
+	return tolerance(relative, opts.BBox.Polygon().Vertices)
+}
+
+// Compute voronoi diagram with given options. Sites and clipping region
+// are validated first, errors are reported instead of panicking.
+func ComputeDiagramWithOptions(sites []Vertex, opts Options) (*Diagram, error) {
+	return NewVoronoi().ComputeWithOptions(sites, opts)
+}
+
+// Compute voronoi diagram like ComputeDiagramWithOptions, reusing memory
+// of the previously computed one. The previous diagram must not be
+// used anymore.
+func (s *Voronoi) ComputeWithOptions(sites []Vertex, opts Options) (diagram *Diagram, err error) {
+	var region clipRegion = opts.BBox
+	if !opts.Unbounded {
+		if opts.Region != nil {
+			region, err = validateRegion(opts.Region)
+		} else {
+			err = validateBBox(opts.BBox)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err = validateSites(sites); err != nil {
+		return nil, err
+	}
+	if !isFinite(opts.Tolerance) || opts.Tolerance < 0 {
+		return nil, ErrInvalidTolerance
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			cellErr, ok := r.(*CellNotFoundError)
+			if !ok {
+				panic(r)
+			}
+			diagram, err = nil, cellErr
+		}
+	}()
+
+	s.Reset()
+	s.epsilon = opts.epsilon(sites)
+
+	siteCells := s.sweep(sites)
+	if opts.Unbounded {
+		s.unbound()
+	} else {
+		s.clip(region, opts.CloseCells)
+	}
+	return s.diagram(siteCells), nil
+}
+
+// Turn dangling edges into rays and lines instead of clipping them
+func (s *Voronoi) unbound() {
+	for i := len(s.edges) - 1; i >= 0; i-- {
+		edge := s.edges[i]
+		va := edge.Va.Vertex
+		vb := edge.Vb.Vertex
+
+		if vb != NO_VERTEX {
+			// discard edges which are point-like
+			if math.Abs(va.X-vb.X) < s.epsilon && math.Abs(va.Y-vb.Y) < s.epsilon {
+				edge.Va.Vertex = NO_VERTEX
+				edge.Vb.Vertex = NO_VERTEX
+				s.discardedEdges = append(s.discardedEdges, edge)
+				s.edges[i] = s.edges[len(s.edges)-1]
+				s.edges = s.edges[0 : len(s.edges)-1]
+			}
+			continue
+		}
+
+		// direction of the bisector from the start point to the end point,
+		// the same connectEdge follows
+		LeftSite := edge.LeftCell.Site
+		RightSite := edge.RightCell.Site
+		dx := RightSite.Y - LeftSite.Y
+		dy := LeftSite.X - RightSite.X
+		length := math.Hypot(dx, dy)
+		edge.Direction = Vertex{dx / length, dy / length}
+
+		if va == NO_VERTEX {
+			// edge between collinear sites, which never got a vertex
+			edge.Kind = LineEdge
+			edge.Va.Vertex = Vertex{(LeftSite.X + RightSite.X) / 2, (LeftSite.Y + RightSite.Y) / 2}
+		} else {
+			edge.Kind = RayEdge
+		}
+		edge.LeftCell.Unbounded = true
+		edge.RightCell.Unbounded = true
+	}
+
+	for _, cell := range s.cells {
+		if cell.prepare() == 0 {
+			// the only site covers whole plane
+			cell.Unbounded = true
+		}
+	}
+}
@@ -8,6 +8,7 @@ package voronoi_test
 
 import (
 	. "github.com/pzsz/voronoi"
+	"math"
 	"math/rand"
 	"testing"
 )
@@ -66,3 +67,239 @@ func Benchmark1000(b *testing.B) {
 	b.StartTimer()
 	ComputeDiagram(sites, NewBBox(0, 100, 0, 100), true)
 }
+
+func TestComputeDiagramErrors(t *testing.T) {
+	bbox := NewBBox(0, 10, 0, 10)
+	sites := []Vertex{
+		Vertex{4, 5},
+		Vertex{6, 5},
+	}
+
+	if _, err := ComputeDiagramE(nil, bbox, true); err != ErrNoSites {
+		t.Errorf("Expected ErrNoSites, got %v", err)
+	}
+	if _, err := ComputeDiagramE(sites, NewBBox(10, 0, 0, 10), true); err == nil {
+		t.Errorf("Expected error for inverted bounding box")
+	} else if _, ok := err.(*BBoxError); !ok {
+		t.Errorf("Expected BBoxError, got %v", err)
+	}
+	if _, err := ComputeDiagramE(sites, NewBBox(0, 10, 10, 0), true); err == nil {
+		t.Errorf("Expected error for inverted bounding box")
+	}
+
+	bad := []Vertex{
+		Vertex{4, 5},
+		Vertex{math.NaN(), 5},
+	}
+	if _, err := ComputeDiagramE(bad, bbox, true); err == nil {
+		t.Errorf("Expected error for NaN site")
+	} else if siteErr, ok := err.(*SiteError); !ok || siteErr.Index != 1 {
+		t.Errorf("Expected SiteError for site 1, got %v", err)
+	}
+	bad[1] = Vertex{4, math.Inf(-1)}
+	if _, err := ComputeDiagramE(bad, bbox, true); err == nil {
+		t.Errorf("Expected error for infinite site")
+	}
+
+	diagram, err := ComputeDiagramE(sites, bbox, true)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	verifyDiagram(diagram, 7, 2, 4, t)
+}
+
+func TestSitesOrder(t *testing.T) {
+	sites := []Vertex{
+		Vertex{5, 8},
+		Vertex{6, 5},
+		Vertex{2, 5},
+		Vertex{6, 5},
+		Vertex{4, 5},
+	}
+	input := append([]Vertex(nil), sites...)
+
+	diagram := ComputeDiagram(sites, NewBBox(0, 10, 0, 10), true)
+	for i := range sites {
+		if sites[i] != input[i] {
+			t.Fatalf("Sites slice was modified: %v", sites)
+		}
+	}
+
+	verifyDiagram(diagram, 12, 4, -1, t)
+	for i, cell := range diagram.Cells {
+		if i > 0 && diagram.Cells[i-1].Index >= cell.Index {
+			t.Errorf("Cells are not in order of sites")
+		}
+		if sites[cell.Index] != cell.Site {
+			t.Errorf("Cell %v has wrong index %d", cell.Site, cell.Index)
+		}
+	}
+
+	for i, site := range sites {
+		cell := diagram.CellForSite(i)
+		if cell == nil || cell.Site != site {
+			t.Errorf("Wrong cell for site %d: %v", i, cell)
+		}
+	}
+	if diagram.CellForSite(3) != diagram.CellForSite(1) || diagram.CellForSite(3).Index != 1 {
+		t.Errorf("Duplicate site should share cell of the first one")
+	}
+	if diagram.CellForSite(len(sites)) != nil {
+		t.Errorf("Expected no cell for site out of range")
+	}
+}
+
+func TestUnboundedDiagram(t *testing.T) {
+	sites := []Vertex{
+		Vertex{4, 5},
+		Vertex{6, 5},
+		Vertex{5, 8},
+		Vertex{5, 6},
+	}
+
+	diagram, err := ComputeDiagramWithOptions(sites, Options{Unbounded: true})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	verifyDiagram(diagram, 6, 4, -1, t)
+
+	rays := 0
+	for _, edge := range diagram.Edges {
+		if edge.Kind == LineEdge {
+			t.Errorf("Unexpected line edge")
+		}
+		if edge.Kind != RayEdge {
+			continue
+		}
+		rays++
+		if edge.Vb.Vertex != NO_VERTEX {
+			t.Errorf("Ray should have no end point")
+		}
+
+		// far along the ray sites of the edge are still the closest ones
+		far := Vertex{edge.Va.X + 1000*edge.Direction.X, edge.Va.Y + 1000*edge.Direction.Y}
+		dist := func(v Vertex) float64 { return math.Hypot(v.X-far.X, v.Y-far.Y) }
+		d := dist(edge.LeftCell.Site)
+		if math.Abs(d-dist(edge.RightCell.Site)) > 1e-6 {
+			t.Errorf("Ray %v leaves the bisector", edge.Direction)
+		}
+		for _, site := range sites {
+			if dist(site) < d-1e-6 {
+				t.Errorf("Ray %v goes into cell of %v", edge.Direction, site)
+			}
+		}
+	}
+	if rays != 3 {
+		t.Errorf("Expected 3 rays not %d", rays)
+	}
+
+	for _, cell := range diagram.Cells {
+		if cell.Unbounded != (cell.Site != Vertex{5, 6}) {
+			t.Errorf("Wrong unbounded flag of cell %v", cell.Site)
+		}
+	}
+
+	// collinear sites are separated by lines
+	diagram, err = ComputeDiagramWithOptions(sites[:2], Options{Unbounded: true})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	verifyDiagram(diagram, 1, 2, 1, t)
+	edge := diagram.Edges[0]
+	if edge.Kind != LineEdge || edge.Va.Vertex != (Vertex{5, 5}) || math.Abs(edge.Direction.X) > 1e-9 {
+		t.Errorf("Expected vertical line through {5 5}, got %v %v", edge.Va.Vertex, edge.Direction)
+	}
+}
+
+// Flatten diagram into comparable description of its cells
+func describeDiagram(diagram *Diagram) [][]Vertex {
+	ret := make([][]Vertex, len(diagram.Cells))
+	for i, cell := range diagram.Cells {
+		ret[i] = append(ret[i], cell.Site)
+		for _, halfedge := range cell.Halfedges {
+			ret[i] = append(ret[i], halfedge.GetStartpoint(), halfedge.GetEndpoint())
+		}
+	}
+	return ret
+}
+
+func TestVoronoiReuse(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	v := NewVoronoi()
+	for run := 0; run < 20; run++ {
+		// alternate between different sizes, so that pools both grow
+		// and shrink
+		sites := make([]Vertex, 1+r.Intn(200))
+		for i := range sites {
+			sites[i] = Vertex{float64(r.Intn(100)), r.Float64() * 100}
+		}
+		bbox := NewBBox(0, 100, 0, 100)
+
+		expected := describeDiagram(ComputeDiagram(sites, bbox, run%2 == 0))
+		var diagram *Diagram
+		if run%3 == 0 {
+			var err error
+			diagram, err = v.ComputeWithOptions(sites, Options{BBox: bbox, CloseCells: run%2 == 0})
+			if err != nil {
+				t.Fatal(err)
+			}
+		} else {
+			diagram = v.Compute(sites, bbox, run%2 == 0)
+		}
+		got := describeDiagram(diagram)
+
+		if len(got) != len(expected) {
+			t.Fatalf("Run %d: expected %d cells, got %d", run, len(expected), len(got))
+		}
+		for i := range got {
+			if len(got[i]) != len(expected[i]) {
+				t.Errorf("Run %d: cell %d differs", run, i)
+				continue
+			}
+			for j := range got[i] {
+				if got[i][j] != expected[i][j] {
+					t.Errorf("Run %d: cell %d differs", run, i)
+					break
+				}
+			}
+		}
+	}
+
+	// zero value works like NewVoronoi
+	var zero Voronoi
+	sites := []Vertex{Vertex{10, 10}, Vertex{40, 70}, Vertex{80, 20}}
+	expected := ComputeDiagram(sites, NewBBox(0, 100, 0, 100), true)
+	if diagram := zero.Compute(sites, NewBBox(0, 100, 0, 100), true); len(diagram.Cells) != 3 || len(diagram.Edges) != len(expected.Edges) {
+		t.Errorf("Zero Voronoi computed %d cells and %d edges", len(diagram.Cells), len(diagram.Edges))
+	}
+}
+
+func benchmarkSites(n int) []Vertex {
+	r := rand.New(rand.NewSource(1234567))
+	sites := make([]Vertex, n)
+	for j := range sites {
+		sites[j] = Vertex{r.Float64() * 100, r.Float64() * 100}
+	}
+	return sites
+}
+
+func BenchmarkComputeDiagram(b *testing.B) {
+	sites := benchmarkSites(1000)
+	bbox := NewBBox(0, 100, 0, 100)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ComputeDiagram(sites, bbox, true)
+	}
+}
+
+func BenchmarkVoronoiCompute(b *testing.B) {
+	sites := benchmarkSites(1000)
+	bbox := NewBBox(0, 100, 0, 100)
+	v := NewVoronoi()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v.Compute(sites, bbox, true)
+	}
+}
@@ -0,0 +1,168 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+// Well-Known Text and Well-Known Binary encoding of cells and edges
+
+package voronoi
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"strconv"
+)
+
+// Geometry types of WKB
+const (
+	wkbLineString         = 2
+	wkbPolygon            = 3
+	wkbGeometryCollection = 7
+)
+
+func appendWKTPoints(b *bytes.Buffer, points [][2]float64) {
+	b.WriteByte('(')
+	for i, p := range points {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(strconv.FormatFloat(p[0], 'f', -1, 64))
+		b.WriteByte(' ')
+		b.WriteString(strconv.FormatFloat(p[1], 'f', -1, 64))
+	}
+	b.WriteByte(')')
+}
+
+// Points of the edge, nil for infinite edges
+func edgePoints(edge *Edge) [][2]float64 {
+	if edge.Kind != SegmentEdge {
+		return nil
+	}
+	return [][2]float64{{edge.Va.X, edge.Va.Y}, {edge.Vb.X, edge.Vb.Y}}
+}
+
+func (t *Cell) appendWKT(b *bytes.Buffer) {
+	ring := cellRing(t)
+	if ring == nil {
+		b.WriteString("POLYGON EMPTY")
+		return
+	}
+	b.WriteString("POLYGON (")
+	appendWKTPoints(b, ring)
+	b.WriteByte(')')
+}
+
+func (e *Edge) appendWKT(b *bytes.Buffer) {
+	points := edgePoints(e)
+	if points == nil {
+		b.WriteString("LINESTRING EMPTY")
+		return
+	}
+	b.WriteString("LINESTRING ")
+	appendWKTPoints(b, points)
+}
+
+// Return the cell as WKT POLYGON, with ring of start vertices of the
+// halfedges. It goes counterclockwise with Y axis pointing up, like in
+// GeoJSON, so in reverse order of the halfedges. Unbounded cells and
+// cells which weren't closed are POLYGON EMPTY.
+func (t *Cell) WKT() string {
+	var b bytes.Buffer
+	t.appendWKT(&b)
+	return b.String()
+}
+
+// Return the edge as WKT LINESTRING from Va to Vb. Infinite edges are
+// LINESTRING EMPTY.
+func (e *Edge) WKT() string {
+	var b bytes.Buffer
+	e.appendWKT(&b)
+	return b.String()
+}
+
+// Return the diagram as WKT GEOMETRYCOLLECTION of cell polygons followed
+// by edge linestrings
+func (d *Diagram) WKT() string {
+	var b bytes.Buffer
+	if len(d.Cells) == 0 && len(d.Edges) == 0 {
+		return "GEOMETRYCOLLECTION EMPTY"
+	}
+	b.WriteString("GEOMETRYCOLLECTION (")
+	for i, cell := range d.Cells {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		cell.appendWKT(&b)
+	}
+	for i, edge := range d.Edges {
+		if i > 0 || len(d.Cells) > 0 {
+			b.WriteString(", ")
+		}
+		edge.appendWKT(&b)
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// WKB is written in little endian byte order
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendFloat64(b []byte, v float64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v))
+	return append(b, buf[:]...)
+}
+
+func appendWKBHeader(b []byte, kind uint32, count int) []byte {
+	b = append(b, 1)
+	b = appendUint32(b, kind)
+	return appendUint32(b, uint32(count))
+}
+
+func appendWKBPoints(b []byte, points [][2]float64) []byte {
+	b = appendUint32(b, uint32(len(points)))
+	for _, p := range points {
+		b = appendFloat64(b, p[0])
+		b = appendFloat64(b, p[1])
+	}
+	return b
+}
+
+func (t *Cell) appendWKB(b []byte) []byte {
+	ring := cellRing(t)
+	if ring == nil {
+		return appendWKBHeader(b, wkbPolygon, 0)
+	}
+	return appendWKBPoints(appendWKBHeader(b, wkbPolygon, 1), ring)
+}
+
+func (e *Edge) appendWKB(b []byte) []byte {
+	b = append(b, 1)
+	b = appendUint32(b, wkbLineString)
+	return appendWKBPoints(b, edgePoints(e))
+}
+
+// Return the cell as WKB Polygon, like WKT does
+func (t *Cell) WKB() []byte {
+	return t.appendWKB(nil)
+}
+
+// Return the edge as WKB LineString, like WKT does
+func (e *Edge) WKB() []byte {
+	return e.appendWKB(nil)
+}
+
+// Return the diagram as WKB GeometryCollection, like WKT does
+func (d *Diagram) WKB() []byte {
+	b := appendWKBHeader(nil, wkbGeometryCollection, len(d.Cells)+len(d.Edges))
+	for _, cell := range d.Cells {
+		b = cell.appendWKB(b)
+	}
+	for _, edge := range d.Edges {
+		b = edge.appendWKB(b)
+	}
+	return b
+}
@@ -0,0 +1,169 @@
+// MIT License: See https://github.com/pzsz/voronoi/LICENSE.md
+
+// Author: Przemyslaw Szczepaniak (przeszczep@gmail.com)
+
+package voronoi_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	. "github.com/pzsz/voronoi"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// Reader of little endian WKB
+type wkbReader struct {
+	data []byte
+	t    *testing.T
+}
+
+func (r *wkbReader) uint32() uint32 {
+	if len(r.data) < 4 {
+		r.t.Fatal("WKB is too short")
+	}
+	v := binary.LittleEndian.Uint32(r.data)
+	r.data = r.data[4:]
+	return v
+}
+
+func (r *wkbReader) header(kind uint32) {
+	if len(r.data) < 1 || r.data[0] != 1 {
+		r.t.Fatal("WKB is not little endian")
+	}
+	r.data = r.data[1:]
+	if k := r.uint32(); k != kind {
+		r.t.Fatalf("Expected WKB geometry %d, got %d", kind, k)
+	}
+}
+
+func (r *wkbReader) points() []Vertex {
+	ret := make([]Vertex, r.uint32())
+	for i := range ret {
+		ret[i].X = math.Float64frombits(uint64(r.uint32()) | uint64(r.uint32())<<32)
+		ret[i].Y = math.Float64frombits(uint64(r.uint32()) | uint64(r.uint32())<<32)
+	}
+	return ret
+}
+
+// Shoelace area of a closed ring, positive if it goes counterclockwise
+// with Y axis pointing up
+func ringArea(ring []Vertex) float64 {
+	area := 0.0
+	for k := 0; k+1 < len(ring); k++ {
+		area += ring[k].X*ring[k+1].Y - ring[k].Y*ring[k+1].X
+	}
+	return area / 2
+}
+
+// Parse ring of WKT POLYGON with a single one
+func wktRing(wkt string, t *testing.T) []Vertex {
+	if !strings.HasPrefix(wkt, "POLYGON ((") || !strings.HasSuffix(wkt, "))") {
+		t.Fatalf("Expected POLYGON with a ring, got %s", wkt)
+	}
+	var ring []Vertex
+	for _, point := range strings.Split(wkt[len("POLYGON (("):len(wkt)-2], ", ") {
+		var v Vertex
+		if _, err := fmt.Sscan(point, &v.X, &v.Y); err != nil {
+			t.Fatalf("Wrong point %q: %v", point, err)
+		}
+		ring = append(ring, v)
+	}
+	return ring
+}
+
+func TestWKT(t *testing.T) {
+	diagram := ComputeDiagram([]Vertex{Vertex{25, 50}, Vertex{75, 50}}, NewBBox(0, 100, 0, 100), true)
+	for _, edge := range diagram.Edges {
+		if !edge.Border {
+			if wkt := edge.WKT(); wkt != "LINESTRING (50 0, 50 100)" && wkt != "LINESTRING (50 100, 50 0)" {
+				t.Errorf("Wrong WKT of edge: %s", wkt)
+			}
+		}
+	}
+	for _, cell := range diagram.Cells {
+		ring := wktRing(cell.WKT(), t)
+		if len(ring) != 5 || ring[0] != ring[4] {
+			t.Errorf("Wrong WKT of cell: %s", cell.WKT())
+		}
+		// half of the box in either cell
+		if area := ringArea(ring); area != 5000 {
+			t.Errorf("Expected area 5000 of cell %d, got %g", cell.Index, area)
+		}
+	}
+	wkt := diagram.WKT()
+	if !strings.HasPrefix(wkt, "GEOMETRYCOLLECTION (POLYGON") || strings.Count(wkt, "POLYGON") != 2 || strings.Count(wkt, "LINESTRING") != len(diagram.Edges) {
+		t.Errorf("Wrong WKT of diagram: %s", wkt)
+	}
+
+	unbounded, err := ComputeDiagramWithOptions([]Vertex{Vertex{0, 0}, Vertex{1, 0}}, Options{Unbounded: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "GEOMETRYCOLLECTION (POLYGON EMPTY, POLYGON EMPTY, LINESTRING EMPTY)"
+	if wkt := unbounded.WKT(); wkt != expected {
+		t.Errorf("Expected %s, got %s", expected, wkt)
+	}
+
+	// cells end at the box, with gaps between their edges
+	open := ComputeDiagram([]Vertex{Vertex{2, 2}, Vertex{8, 3}, Vertex{5, 8}}, NewBBox(0, 10, 0, 10), false)
+	for _, cell := range open.Cells {
+		if wkt := cell.WKT(); wkt != "POLYGON EMPTY" {
+			t.Errorf("Expected POLYGON EMPTY for cell which wasn't closed, got %s", wkt)
+		}
+		if wkb := cell.WKB(); len(wkb) != 1+4+4 {
+			t.Errorf("Expected Polygon without rings for cell which wasn't closed, got %d bytes", len(wkb))
+		}
+	}
+}
+
+func TestWKB(t *testing.T) {
+	r := rand.New(rand.NewSource(25))
+	sites := make([]Vertex, 30)
+	for i := range sites {
+		sites[i] = Vertex{r.Float64() * 100, r.Float64() * 100}
+	}
+	diagram := ComputeDiagram(sites, NewBBox(0, 100, 0, 100), true)
+
+	reader := &wkbReader{diagram.WKB(), t}
+	reader.header(7)
+	if n := int(reader.uint32()); n != len(diagram.Cells)+len(diagram.Edges) {
+		t.Fatalf("Expected %d geometries, got %d", len(diagram.Cells)+len(diagram.Edges), n)
+	}
+	for _, cell := range diagram.Cells {
+		reader.header(3)
+		if n := reader.uint32(); n != 1 {
+			t.Fatalf("Expected one ring, got %d", n)
+		}
+		ring := reader.points()
+		if len(ring) != len(cell.Halfedges)+1 || ring[0] != ring[len(ring)-1] {
+			t.Fatalf("Ring of cell %d is not closed", cell.Index)
+		}
+		// ring goes against the halfedges, from the same start
+		n := len(cell.Halfedges)
+		for i := range cell.Halfedges {
+			if ring[i] != cell.Halfedges[(n-i)%n].GetStartpoint() {
+				t.Errorf("Ring of cell %d doesn't follow its halfedges", cell.Index)
+			}
+		}
+		if ringArea(ring) <= 0 {
+			t.Errorf("Ring of cell %d is not counterclockwise", cell.Index)
+		}
+	}
+	for _, edge := range diagram.Edges {
+		reader.header(2)
+		points := reader.points()
+		if len(points) != 2 || points[0] != edge.Va.Vertex || points[1] != edge.Vb.Vertex {
+			t.Errorf("Wrong points of edge: %v", points)
+		}
+	}
+	if len(reader.data) != 0 {
+		t.Errorf("%d bytes left after the collection", len(reader.data))
+	}
+
+	if wkb := diagram.Cells[0].WKB(); len(wkb) != 1+4+4+4+16*(len(diagram.Cells[0].Halfedges)+1) {
+		t.Errorf("Wrong length of cell WKB: %d", len(wkb))
+	}
+}